@@ -0,0 +1,207 @@
+// Package client is a typed Go client of the subscriptions API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// The representations of the API, shared with the server so that they cannot drift apart.
+type (
+	Subscription       = model.ExternalData
+	SubscriptionDetail = model.SubscriptionDetail
+	PriceChange        = model.PriceChange
+)
+
+// Authenticator sets the credentials of a request before it is sent.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to Authenticator.
+type AuthenticatorFunc func(r *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// Identity authenticates with the identity headers that the gateway in front of the service sets.
+type Identity struct {
+	UserId string
+	Role   string
+}
+
+func (i Identity) Authenticate(r *http.Request) error {
+	r.Header.Set(auth.UserIdHeader, i.UserId)
+	r.Header.Set(auth.UserRoleHeader, i.Role)
+	return nil
+}
+
+// Retry configures the retries of requests failing with 429 or 5xx. The wait before retry n is a random duration
+// up to Backoff*2^n, at most MaxBackoff, unless the response sets Retry-After.
+type Retry struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultRetry = Retry{
+	MaxAttempts: 3,
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func WithAuth(authenticator Authenticator) Option {
+	return func(c *Client) {
+		c.authenticator = authenticator
+	}
+}
+
+func WithRetry(retry Retry) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// apiVersion is the prefix of the API version whose representation the types of the client are.
+const apiVersion = "/v1"
+
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	authenticator Authenticator
+	retry         Retry
+}
+
+// New returns a client of the API at baseURL, such as http://localhost:8080.
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetry,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// do sends the request, retrying it while the server is overloaded or failing, and decodes the response into out
+// when it is set. A POST is only retried when the server did not process it: on 429 and 503.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	target := c.baseURL + apiVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err := c.send(ctx, method, target, body)
+		if err != nil {
+			return err
+		}
+
+		if response.StatusCode < http.StatusBadRequest {
+			err := decode(response, out)
+			response.Body.Close()
+			return err
+		}
+
+		failure := readError(response)
+		response.Body.Close()
+		if attempt >= c.retry.MaxAttempts || !retryable(method, response.StatusCode) {
+			return failure
+		}
+
+		timer := time.NewTimer(c.backoff(attempt, response.Header.Get("Retry-After")))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target string, body []byte) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Accept", "application/json")
+
+	if c.authenticator != nil {
+		if err := c.authenticator.Authenticate(request); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.httpClient.Do(request)
+}
+
+func (c *Client) backoff(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	wait := c.retry.Backoff << (attempt - 1)
+	if wait <= 0 || wait > c.retry.MaxBackoff {
+		wait = c.retry.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	return rand.N(wait) + 1
+}
+
+func retryable(method string, status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests, status == http.StatusServiceUnavailable:
+		return true
+	case status >= http.StatusInternalServerError:
+		return method != http.MethodPost
+	default:
+		return false
+	}
+}
+
+func decode(response *http.Response, out any) error {
+	if out == nil {
+		_, err := io.Copy(io.Discard, response.Body)
+		return err
+	}
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
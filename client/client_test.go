@@ -0,0 +1,496 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/handler"
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// fakeSubscriptions keeps subscriptions in memory. Users see their own subscriptions, admins all of them; two live
+// subscriptions of a user to a service overlap.
+type fakeSubscriptions struct {
+	mu            sync.Mutex
+	nextId        int64
+	subscriptions map[int64]*model.ExternalData
+}
+
+func newFakeSubscriptions() *fakeSubscriptions {
+	return &fakeSubscriptions{subscriptions: make(map[int64]*model.ExternalData)}
+}
+
+func (s *fakeSubscriptions) authorize(ctx context.Context, ownerId string) error {
+	actor, ok := auth.FromContext(ctx)
+	if !ok {
+		return err_msg.Unauthorized
+	}
+
+	if actor.Role != "admin" && actor.ID != ownerId {
+		return err_msg.Forbidden
+	}
+
+	return nil
+}
+
+func (s *fakeSubscriptions) validate(data *model.ExternalData) error {
+	start, err := time.Parse("01-2006", data.StartDate)
+	if err != nil {
+		return err
+	}
+
+	if data.EndDate != "" {
+		end, err := time.Parse("01-2006", data.EndDate)
+		if err != nil {
+			return err
+		}
+		if end.Before(start) {
+			return err_msg.LaterDate
+		}
+	}
+
+	for _, subscription := range s.subscriptions {
+		if subscription.ID != data.ID &&
+			subscription.UserId == data.UserId &&
+			subscription.ServiceName == data.ServiceName &&
+			subscription.DeletedAt == "" {
+			return &err_msg.OverlapError{SubscriptionId: subscription.ID}
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeSubscriptions) find(ctx context.Context, subscriptionId int64) (*model.ExternalData, error) {
+	subscription, ok := s.subscriptions[subscriptionId]
+	if !ok || subscription.DeletedAt != "" {
+		return nil, err_msg.NoRowsAffected
+	}
+
+	if err := s.authorize(ctx, subscription.UserId); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *fakeSubscriptions) Create(ctx context.Context, data *model.ExternalData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.authorize(ctx, data.UserId); err != nil {
+		return err
+	}
+
+	data.ID = 0
+	if err := s.validate(data); err != nil {
+		return err
+	}
+
+	s.nextId++
+	created := *data
+	created.ID = s.nextId
+	s.subscriptions[created.ID] = &created
+	return nil
+}
+
+func (s *fakeSubscriptions) Read(ctx context.Context, subscriptionId int64, _ *model.Filter) (*model.SubscriptionDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, err := s.find(ctx, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SubscriptionDetail{
+		ExternalData:    *subscription,
+		EffectivePrices: []*model.PriceChange{{Month: subscription.StartDate, Price: subscription.Price}},
+	}, nil
+}
+
+func (s *fakeSubscriptions) Update(ctx context.Context, subscriptionId int64, data *model.ExternalData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.find(ctx, subscriptionId); err != nil {
+		return err
+	}
+
+	data.ID = subscriptionId
+	if err := s.validate(data); err != nil {
+		return err
+	}
+
+	updated := *data
+	s.subscriptions[subscriptionId] = &updated
+	return nil
+}
+
+func (s *fakeSubscriptions) Delete(ctx context.Context, subscriptionId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, err := s.find(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	subscription.DeletedAt = time.Now().Format(time.RFC3339)
+	return nil
+}
+
+func (s *fakeSubscriptions) List(ctx context.Context, filter *model.Filter) ([]*model.ExternalData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actor, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, err_msg.Unauthorized
+	}
+
+	var list []*model.ExternalData
+	for _, subscription := range s.subscriptions {
+		if subscription.ID <= filter.AfterId ||
+			(subscription.DeletedAt != "" && !filter.IncludeDeleted) ||
+			(actor.Role != "admin" && subscription.UserId != actor.ID) ||
+			(filter.UserId != "" && subscription.UserId != filter.UserId) {
+			continue
+		}
+		list = append(list, subscription)
+	}
+
+	slices.SortFunc(list, func(a, b *model.ExternalData) int {
+		return int(a.ID - b.ID)
+	})
+	if filter.Limit > 0 && len(list) > filter.Limit {
+		list = list[:filter.Limit]
+	}
+
+	return list, nil
+}
+
+func (s *fakeSubscriptions) Total(ctx context.Context, data *model.ExternalData, _ *model.Filter) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.authorize(ctx, data.UserId); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, subscription := range s.subscriptions {
+		if subscription.UserId == data.UserId && subscription.ServiceName == data.ServiceName && subscription.DeletedAt == "" {
+			total += subscription.Price
+		}
+	}
+
+	return total, nil
+}
+
+func (s *fakeSubscriptions) Restore(context.Context, int64) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) History(context.Context, int64, *model.AuditFilter) ([]*model.AuditRecord, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) Pause(context.Context, int64) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) Resume(context.Context, int64) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) Cancel(context.Context, int64, string) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) AddDiscount(context.Context, int64, *model.Discount) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) RemoveDiscount(context.Context, int64, int64) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) Discounts(context.Context, int64) ([]*model.Discount, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) AddMember(context.Context, int64, *model.Member) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) RemoveMember(context.Context, int64, int64, string) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) Members(context.Context, int64) ([]*model.Member, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) SchedulePrice(context.Context, int64, *model.ScheduledPrice) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) RemovePrice(context.Context, int64, int64) error {
+	return errors.ErrUnsupported
+}
+
+func (s *fakeSubscriptions) ScheduledPrices(context.Context, int64) ([]*model.ScheduledPrice, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// newServer serves the routes of the API over the fake services and returns its URL. The handler wrapping them may
+// fail requests before they reach the routes.
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) string {
+	t.Helper()
+
+	routes := handler.New(newFakeSubscriptions(), nil, nil, nil, nil, nil, nil, http.NotFoundHandler(), nil, nil).InitRoutes()
+	if wrap != nil {
+		routes = wrap(routes)
+	}
+
+	server := httptest.NewServer(routes)
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func newClient(t *testing.T, identity Identity) (*Client, string) {
+	t.Helper()
+
+	baseURL := newServer(t, nil)
+	return New(baseURL, WithAuth(identity)), baseURL
+}
+
+func subscription(userId, serviceName string, price int64) *Subscription {
+	return &Subscription{
+		ServiceName: serviceName,
+		Price:       price,
+		UserId:      userId,
+		StartDate:   "01-2025",
+	}
+}
+
+func TestCreateReadUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t, Identity{UserId: "alice", Role: "user"})
+
+	if err := c.Create(ctx, subscription("alice", "Netflix", 400)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	detail, err := c.Read(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if detail.ServiceName != "Netflix" || detail.Price != 400 || detail.UserId != "alice" {
+		t.Errorf("Read = %+v, want the created subscription", detail.ExternalData)
+	}
+	if len(detail.EffectivePrices) != 1 || detail.EffectivePrices[0].Price != 400 {
+		t.Errorf("effective prices = %v, want one of 400", detail.EffectivePrices)
+	}
+
+	if err := c.Update(ctx, 1, subscription("alice", "Netflix", 500)); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if detail, err = c.Read(ctx, 1, nil); err != nil {
+		t.Fatalf("Read after Update: %v", err)
+	}
+	if detail.Price != 500 {
+		t.Errorf("price after Update = %d, want 500", detail.Price)
+	}
+
+	if err := c.Delete(ctx, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.Read(ctx, 1, nil); !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrNoRowsAffected) {
+		t.Errorf("Read after Delete: error %v, want ErrNotFound and ErrNoRowsAffected", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t, Identity{UserId: "alice", Role: "user"})
+
+	for i := range 5 {
+		if err := c.Create(ctx, subscription("alice", fmt.Sprintf("service %d", i), 100)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	var ids []int64
+	for subscription, err := range c.List(ctx, &Filter{PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		ids = append(ids, subscription.ID)
+	}
+
+	if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(ids, want) {
+		t.Errorf("List = %v, want %v across pages", ids, want)
+	}
+
+	// Stopping the iteration early does not request further pages.
+	for range c.List(ctx, &Filter{PageSize: 2}) {
+		break
+	}
+}
+
+func TestListStopsAtError(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t, Identity{})
+
+	var errs []error
+	for subscription, err := range c.List(ctx, nil) {
+		if subscription != nil {
+			t.Errorf("List yielded %+v without credentials", subscription)
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], ErrUnauthorized) {
+		t.Errorf("List errors = %v, want one ErrUnauthorized", errs)
+	}
+}
+
+func TestTotal(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t, Identity{UserId: "alice", Role: "user"})
+
+	if err := c.Create(ctx, subscription("alice", "Netflix", 400)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	total, err := c.Total(ctx, &Period{UserId: "alice", ServiceName: "Netflix", StartDate: "01-2025", EndDate: "12-2025"}, nil)
+	if err != nil {
+		t.Fatalf("Total: %v", err)
+	}
+	if total != 400 {
+		t.Errorf("Total = %d, want 400", total)
+	}
+
+	if _, err := c.Total(ctx, &Period{UserId: "alice"}, nil); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Total without the period: error %v, want ErrBadRequest", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c, baseURL := newClient(t, Identity{UserId: "alice", Role: "user"})
+
+	if err := c.Create(ctx, subscription("alice", "Netflix", 400)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	bob := New(baseURL, WithAuth(Identity{UserId: "bob", Role: "user"}))
+
+	later := subscription("alice", "Spotify", 200)
+	later.EndDate = "12-2024"
+
+	tests := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{
+			name: "end before start",
+			err:  c.Create(ctx, later),
+			want: []error{ErrBadRequest, ErrLaterDate},
+		},
+		{
+			name: "overlap",
+			err:  c.Create(ctx, subscription("alice", "Netflix", 400)),
+			want: []error{ErrConflict, ErrOverlap},
+		},
+		{
+			name: "missing",
+			err:  c.Update(ctx, 42, subscription("alice", "Netflix", 400)),
+			want: []error{ErrNotFound, ErrNoRowsAffected},
+		},
+		{
+			name: "foreign",
+			err:  bob.Delete(ctx, 1),
+			want: []error{ErrForbidden},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *Error
+			if !errors.As(tt.err, &apiErr) {
+				t.Fatalf("error %v, want an *Error", tt.err)
+			}
+
+			for _, want := range tt.want {
+				if !errors.Is(tt.err, want) {
+					t.Errorf("error %v, want it to match %v", tt.err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		failures = map[string]int{}
+	)
+	// The first request of each method is answered with 503 and the second POST with 500.
+	baseURL := newServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			failures[r.Method]++
+			n := failures[r.Method]
+			mu.Unlock()
+
+			switch {
+			case n == 1:
+				w.Header().Set("Retry-After", "0")
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			case n == 2 && r.Method == http.MethodPost:
+				http.Error(w, "failed", http.StatusInternalServerError)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	})
+	c := New(baseURL,
+		WithAuth(Identity{UserId: "alice", Role: "user"}),
+		WithRetry(Retry{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+	)
+
+	// A POST is retried after 503, but not after 500, which the server may have processed.
+	if err := c.Create(ctx, subscription("alice", "Netflix", 400)); !errors.Is(err, ErrServer) {
+		t.Fatalf("Create: error %v, want ErrServer", err)
+	}
+	if failures[http.MethodPost] != 2 {
+		t.Errorf("Create sent %d requests, want 2", failures[http.MethodPost])
+	}
+
+	if err := c.Create(ctx, subscription("alice", "Netflix", 400)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A GET is retried until it succeeds.
+	if _, err := c.Read(ctx, 1, nil); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if failures[http.MethodGet] != 2 {
+		t.Errorf("Read sent %d requests, want 2", failures[http.MethodGet])
+	}
+}
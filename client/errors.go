@@ -0,0 +1,109 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+)
+
+// Errors by response status.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// Errors of the service, recognized by the message of the response.
+var (
+	ErrLaterDate         = err_msg.LaterDate
+	ErrInvalidTrial      = err_msg.InvalidTrial
+	ErrInvalidCancel     = err_msg.InvalidCancel
+	ErrInvalidDiscount   = err_msg.InvalidDiscount
+	ErrInvalidMember     = err_msg.InvalidMember
+	ErrInvalidPrice      = err_msg.InvalidPrice
+	ErrInvalidPage       = err_msg.InvalidPage
+	ErrOverlap           = err_msg.Overlap
+	ErrInvalidTransition = err_msg.InvalidTransition
+	ErrNoRowsAffected    = err_msg.NoRowsAffected
+)
+
+var serviceErrors = []error{
+	ErrLaterDate,
+	ErrInvalidTrial,
+	ErrInvalidCancel,
+	ErrInvalidDiscount,
+	ErrInvalidMember,
+	ErrInvalidPrice,
+	ErrInvalidPage,
+	ErrOverlap,
+	ErrInvalidTransition,
+	ErrNoRowsAffected,
+}
+
+// Error is an error response of the API. errors.Is matches it against the error of its status and, when the
+// message names one, the error of the service.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	var wrapped []error
+	if status := statusError(e.StatusCode); status != nil {
+		wrapped = append(wrapped, status)
+	}
+
+	for _, serviceError := range serviceErrors {
+		if strings.HasPrefix(e.Message, serviceError.Error()) {
+			wrapped = append(wrapped, serviceError)
+			break
+		}
+	}
+
+	return wrapped
+}
+
+func statusError(status int) error {
+	switch {
+	case status == http.StatusBadRequest:
+		return ErrBadRequest
+	case status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusConflict:
+		return ErrConflict
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= http.StatusInternalServerError:
+		return ErrServer
+	default:
+		return nil
+	}
+}
+
+func readError(response *http.Response) error {
+	message, err := io.ReadAll(io.LimitReader(response.Body, 4096))
+	if err != nil {
+		return err
+	}
+
+	return &Error{
+		StatusCode: response.StatusCode,
+		Message:    strings.TrimSpace(string(message)),
+	}
+}
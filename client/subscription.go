@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultPageSize is the number of subscriptions List requests at a time.
+const DefaultPageSize = 100
+
+// Filter selects the subscriptions of Read, List and Total. The zero value selects the current live subscriptions
+// visible to the caller.
+type Filter struct {
+	UserId          string
+	IncludeDeleted  bool
+	AsOf            *time.Time
+	TrialEndsWithin time.Duration
+	PageSize        int
+}
+
+// Period selects the subscriptions of a user to a service for Total. Dates are MM-YYYY.
+type Period struct {
+	UserId      string
+	ServiceName string
+	StartDate   string
+	EndDate     string
+}
+
+func (c *Client) Create(ctx context.Context, subscription *Subscription) error {
+	return c.do(ctx, http.MethodPost, "/subscriptions", nil, subscription, nil)
+}
+
+func (c *Client) Read(ctx context.Context, id int64, filter *Filter) (*SubscriptionDetail, error) {
+	subscription := new(SubscriptionDetail)
+	if err := c.do(ctx, http.MethodGet, subscriptionPath(id), filter.query(), nil, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (c *Client) Update(ctx context.Context, id int64, subscription *Subscription) error {
+	return c.do(ctx, http.MethodPut, subscriptionPath(id), nil, subscription, nil)
+}
+
+func (c *Client) Delete(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, subscriptionPath(id), nil, nil, nil)
+}
+
+// List iterates over the subscriptions selected by the filter in ID order, requesting them a page at a time. The
+// iteration stops after the first error.
+func (c *Client) List(ctx context.Context, filter *Filter) iter.Seq2[*Subscription, error] {
+	return func(yield func(*Subscription, error) bool) {
+		pageSize := DefaultPageSize
+		if filter != nil && filter.PageSize > 0 {
+			pageSize = filter.PageSize
+		}
+
+		query := filter.query()
+		query.Set("limit", strconv.Itoa(pageSize))
+		if filter != nil && filter.TrialEndsWithin > 0 {
+			query.Set("trial_ends_within", filter.TrialEndsWithin.String())
+		}
+
+		var afterId int64
+		for {
+			query.Set("after_id", strconv.FormatInt(afterId, 10))
+
+			var page []*Subscription
+			if err := c.do(ctx, http.MethodGet, "/subscriptions", query, nil, &page); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, subscription := range page {
+				if !yield(subscription, nil) {
+					return
+				}
+				afterId = subscription.ID
+			}
+
+			if len(page) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// Total returns the cost of the subscriptions of the period.
+func (c *Client) Total(ctx context.Context, period *Period, filter *Filter) (int64, error) {
+	query := filter.query()
+	query.Set("user_id", period.UserId)
+	query.Set("service_name", period.ServiceName)
+	query.Set("start_date", period.StartDate)
+	query.Set("end_date", period.EndDate)
+
+	var total int64
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/total", query, nil, &total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (f *Filter) query() url.Values {
+	query := url.Values{}
+	if f == nil {
+		return query
+	}
+
+	if f.UserId != "" {
+		query.Set("user_id", f.UserId)
+	}
+
+	if f.IncludeDeleted {
+		query.Set("include_deleted", "true")
+	}
+
+	if f.AsOf != nil {
+		query.Set("as_of", f.AsOf.Format(time.RFC3339))
+	}
+
+	return query
+}
+
+func subscriptionPath(id int64) string {
+	return "/subscriptions/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,59 @@
+// Command costs maintains the subscription_monthly_costs aggregate: rebuild recomputes it from the subscriptions
+// for backfills, check compares it with the subscriptions and exits with status 1 on any mismatch.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/oatsmoke/20250905/internal/lib/env"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/lib/postgres_db"
+	"github.com/oatsmoke/20250905/internal/repository"
+)
+
+func main() {
+	logger.New()
+
+	if len(os.Args) != 2 || (os.Args[1] != "rebuild" && os.Args[1] != "check") {
+		fmt.Fprintln(os.Stderr, "usage: costs rebuild|check")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	postgresDB := postgres_db.Connect(ctx, env.GetPostgresDsn(), env.GetPostgresMaxConns())
+	defer postgresDB.Close()
+
+	costR := repository.NewCostRepository(postgresDB)
+
+	if os.Args[1] == "rebuild" {
+		if _, err := costR.Rebuild(ctx); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	mismatches, err := costR.Check(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, mismatch := range mismatches {
+		if err := encoder.Encode(mismatch); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		postgresDB.Close()
+		os.Exit(1)
+	}
+}
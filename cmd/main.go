@@ -2,40 +2,152 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"log"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/oatsmoke/20250905/docs"
+	"github.com/oatsmoke/20250905/internal/cache"
+	"github.com/oatsmoke/20250905/internal/graph"
 	"github.com/oatsmoke/20250905/internal/handler"
 	"github.com/oatsmoke/20250905/internal/lib/env"
+	"github.com/oatsmoke/20250905/internal/lib/grpc_server"
+	"github.com/oatsmoke/20250905/internal/lib/http_client"
 	"github.com/oatsmoke/20250905/internal/lib/http_server"
+	"github.com/oatsmoke/20250905/internal/lib/leader"
 	"github.com/oatsmoke/20250905/internal/lib/logger"
 	"github.com/oatsmoke/20250905/internal/lib/postgres_db"
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/oatsmoke/20250905/internal/notifier"
+	"github.com/oatsmoke/20250905/internal/publisher"
 	"github.com/oatsmoke/20250905/internal/repository"
+	"github.com/oatsmoke/20250905/internal/rpc"
 	"github.com/oatsmoke/20250905/internal/service"
+	"github.com/oatsmoke/20250905/internal/worker"
 )
 
-// @title Users online subscriptions
-// @version 1.0
 func main() {
 	logger.New()
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	postgresDB := postgres_db.Connect(ctx, env.GetPostgresDsn())
+	postgresDB := postgres_db.Connect(ctx, env.GetPostgresDsn(), env.GetPostgresMaxConns())
 	defer postgresDB.Close()
 
-	newR := repository.New(postgresDB)
-	newS := service.New(newR)
-	newH := handler.New(newS)
+	policy, err := service.LoadPolicy(env.GetPolicyFile())
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	httpPort := env.GetHttpPort()
-	docs.SwaggerInfo.Host = fmt.Sprintf("localhost%s", httpPort)
-	httpS := http_server.New(httpPort, newH)
+	newR := repository.New(postgresDB, env.GetSubscriptionNoOverlap())
+	webhookR := repository.NewWebhookRepository(postgresDB)
+	outboxR := repository.NewOutboxRepository(postgresDB)
+	reminderR := repository.NewReminderRepository(postgresDB)
+	preferenceR := repository.NewPreferenceRepository(postgresDB)
+	emailR := repository.NewEmailRepository(postgresDB)
+	budgetR := repository.NewBudgetRepository(postgresDB)
+	reportR := repository.NewReportRepository(postgresDB)
+	var subscriptionR cache.Subscription = newR
+	if backend := env.GetCacheBackend(); backend != cache.BackendNone {
+		store, err := cache.New(backend, env.GetCacheSize(), env.GetRedisAddr())
+		if err != nil {
+			log.Fatal(err)
+		}
+		subscriptionR = cache.NewSubscription(newR, store, env.GetCacheTtl())
+	}
+
+	newS := service.New(subscriptionR, policy)
+	webhookS := service.NewWebhookService(webhookR, policy)
+	streamS := service.NewStreamService(outboxR, policy)
+	preferenceS := service.NewPreferenceService(preferenceR, policy)
+	budgetS := service.NewBudgetService(budgetR, subscriptionR, policy)
+	reportS := service.NewReportService(reportR, policy)
+	debugS := service.NewDebugService(policy)
+	graphQLH := graph.NewHandler(newS, budgetS, env.GetGraphqlMaxDepth(), env.GetGraphqlMaxComplexity())
+	newH := handler.New(newS, webhookS, streamS, preferenceS, budgetS, reportS, debugS, graphQLH, map[string]handler.Lifecycle{
+		"v1": {Deprecation: env.GetApiV1Deprecation(), Sunset: env.GetApiV1Sunset()},
+	}, env.GetCorsAllowedOrigins())
+
+	go streamS.Run(ctx)
+
+	purgeW := worker.NewPurgeWorker(subscriptionR, env.GetPurgeRetention(), env.GetPurgeInterval())
+	go purgeW.Run(ctx)
+
+	webhookW := worker.NewWebhookWorker(
+		webhookR,
+		http_client.NewPublic(env.GetWebhookTimeout()),
+		env.GetWebhookInterval(),
+		env.GetWebhookMaxAttempts(),
+	)
+	go webhookW.Run(ctx)
+
+	eventsP, err := publisher.New(env.GetEventsPublisher())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer eventsP.Close()
+
+	relayW := worker.NewRelayWorker(outboxR, eventsP, env.GetEventsInterval())
+	go relayW.Run(ctx)
+
+	smtpN, err := notifier.NewSMTP(
+		env.GetSmtpAddr(),
+		env.GetSmtpUsername(),
+		env.GetSmtpPassword(),
+		env.GetSmtpFrom(),
+		env.GetSmtpTls(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	emailW := worker.NewEmailWorker(
+		emailR,
+		smtpN,
+		env.GetEmailInterval(),
+		env.GetEmailMaxAttempts(),
+		env.GetEmailTimeout(),
+	)
+	go emailW.Run(ctx)
+
+	summaryW := worker.NewSummaryWorker(emailR, env.GetSummaryInterval())
+	go leader.Run(ctx, env.GetPostgresDsn(), "summaries", time.Minute, summaryW.Run)
+
+	budgetW := worker.NewBudgetWorker(budgetS, env.GetBudgetInterval())
+	go leader.Run(ctx, env.GetPostgresDsn(), "budgets", time.Minute, budgetW.Run)
+
+	trialW := worker.NewTrialWorker(subscriptionR, env.GetTrialInterval())
+	go leader.Run(ctx, env.GetPostgresDsn(), "trials", time.Minute, trialW.Run)
+
+	endingW := worker.NewEndingWorker(subscriptionR, env.GetEndingInterval())
+	go leader.Run(ctx, env.GetPostgresDsn(), "endings", time.Minute, endingW.Run)
+
+	notifiers := notifier.Router{
+		model.ChannelLog:     notifier.NewLog(),
+		model.ChannelEmail:   notifier.NewQueue(emailR),
+		model.ChannelWebhook: notifier.NewWebhook(http_client.NewPublic(env.GetWebhookTimeout()), env.GetNotifierWebhookSecret()),
+	}
+	reminderW := worker.NewReminderWorker(
+		reminderR,
+		notifiers,
+		env.GetReminderInterval(),
+		env.GetReminderRenewalWindow(),
+		env.GetReminderEndWindow(),
+	)
+	go leader.Run(ctx, env.GetPostgresDsn(), "reminders", time.Minute, reminderW.Run)
+
+	httpS := http_server.New(env.GetHttpPort(), newH)
 	httpS.Run()
-	defer httpS.Stop(ctx)
+
+	grpcS := grpc_server.New(env.GetGrpcPort(), rpc.NewSubscriptionServer(newS))
+	grpcS.Run()
 
 	<-ctx.Done()
+
+	// ctx is done by now, so the servers get a fresh deadline to finish the requests in flight.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	httpS.Stop(shutdownCtx)
+	grpcS.Stop(shutdownCtx)
 }
@@ -0,0 +1,32 @@
+// Command openapi works with the OpenAPI documents the API versions serve at /v1/openapi.json and /v2/openapi.json:
+// write prints the document of a version, v1 by default. The tests of internal/handler check that the routes serve
+// the operations of the documents.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/oatsmoke/20250905/internal/handler"
+)
+
+func main() {
+	if len(os.Args) < 2 || len(os.Args) > 3 || os.Args[1] != "write" {
+		fmt.Fprintln(os.Stderr, "usage: openapi write [version]")
+		os.Exit(2)
+	}
+
+	version := "v1"
+	if len(os.Args) == 3 {
+		version = os.Args[2]
+	}
+
+	document, err := handler.OpenAPIDocument(version)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := os.Stdout.Write(document); err != nil {
+		log.Fatal(err)
+	}
+}
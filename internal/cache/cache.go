@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// Store keeps cached values with a time to live and generation counters that are never expired.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Generation(ctx context.Context, key string) (int64, error)
+	Bump(ctx context.Context, keys ...string) error
+}
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendNone   = "none"
+)
+
+// metrics counts hits and misses per cached operation, published at /debug/vars.
+var metrics = expvar.NewMap("cache")
+
+func hit(operation string) {
+	metrics.Add(operation+"_hits", 1)
+}
+
+func miss(operation string) {
+	metrics.Add(operation+"_misses", 1)
+}
+
+func New(backend string, size int, redisAddr string) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return NewLRU(size), nil
+	case BackendRedis:
+		return NewRedis(redisAddr), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", backend)
+	}
+}
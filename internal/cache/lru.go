@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRU is an in-process store that evicts the least recently used value once it holds size values.
+// Generations are kept apart from the values, so that eviction never resets them.
+type LRU struct {
+	mu          sync.Mutex
+	size        int
+	items       map[string]*list.Element
+	order       *list.List
+	generations map[string]int64
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:        size,
+		items:       make(map[string]*list.Element),
+		order:       list.New(),
+		generations: make(map[string]int64),
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	item := element.Value.(*entry)
+	if time.Now().After(item.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, key)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(element)
+	return item.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		item := element.Value.(*entry)
+		item.value = value
+		item.expiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+
+	return nil
+}
+
+func (c *LRU) Generation(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generations[key], nil
+}
+
+func (c *LRU) Bump(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		c.generations[key]++
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a store shared by all instances on any server speaking the Redis protocol.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(addr string) *Redis {
+	return &Redis{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+	}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *Redis) Generation(ctx context.Context, key string) (int64, error) {
+	generation, err := c.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	return generation, err
+}
+
+func (c *Redis) Bump(ctx context.Context, keys ...string) error {
+	pipe := c.client.TxPipeline()
+	for _, key := range keys {
+		pipe.Incr(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
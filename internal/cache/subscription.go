@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+	"golang.org/x/sync/singleflight"
+)
+
+type Subscription interface {
+	Create(ctx context.Context, subscription *model.Subscription) error
+	Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.Subscription, error)
+	Update(ctx context.Context, subscription *model.Subscription) error
+	Delete(ctx context.Context, subscriptionId int64) error
+	Restore(ctx context.Context, subscriptionId int64) error
+	List(ctx context.Context, filter *model.Filter) ([]*model.Subscription, error)
+	Total(ctx context.Context, subscription *model.Subscription, filter *model.Filter) (int64, error)
+	Totals(ctx context.Context, subscriptions []*model.Subscription) ([]int64, error)
+	History(ctx context.Context, subscriptionId int64, filter *model.AuditFilter) ([]*model.AuditRecord, error)
+	Pause(ctx context.Context, subscription *model.Subscription, from time.Time) error
+	Resume(ctx context.Context, subscription *model.Subscription, at time.Time) error
+	Cancel(ctx context.Context, subscription *model.Subscription, endDate time.Time) error
+	AddDiscount(ctx context.Context, subscription *model.Subscription, discount *model.Discount) error
+	RemoveDiscount(ctx context.Context, subscription *model.Subscription, discountId int64) error
+	Discounts(ctx context.Context, subscriptionId int64) ([]*model.Discount, error)
+	EffectivePrices(ctx context.Context, subscriptionId int64) ([]*model.PriceChange, error)
+	AddMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error
+	RemoveMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error
+	Members(ctx context.Context, subscriptionId int64) ([]*model.Member, error)
+	SchedulePrice(ctx context.Context, subscription *model.Subscription, price *model.ScheduledPrice) error
+	RemovePrice(ctx context.Context, subscription *model.Subscription, priceId int64) error
+	ScheduledPrices(ctx context.Context, subscriptionId int64) ([]*model.ScheduledPrice, error)
+	Purge(ctx context.Context, deletedBefore time.Time) ([]*model.Subscription, error)
+	ConvertTrials(ctx context.Context, now time.Time) ([]*model.Subscription, error)
+	EndSubscriptions(ctx context.Context, now time.Time) ([]*model.Subscription, error)
+}
+
+// SubscriptionCache caches current reads and totals of the wrapped repository.
+//
+// Cache keys embed the generation of what they depend on: a subscription, the subscriptions of a user, or the
+// subscriptions of a user to a service. A mutation bumps the generations it affects, so older keys are never read
+// again and expire with their TTL, while unrelated users and services keep their cached values.
+type SubscriptionCache struct {
+	next  Subscription
+	store Store
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+func NewSubscription(next Subscription, store Store, ttl time.Duration) *SubscriptionCache {
+	return &SubscriptionCache{
+		next:  next,
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+func (c *SubscriptionCache) Create(ctx context.Context, subscription *model.Subscription) error {
+	if err := c.next.Create(ctx, subscription); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.Subscription, error) {
+	if filter.AsOf != nil || filter.IncludeDeleted {
+		return c.next.Read(ctx, subscriptionId, filter)
+	}
+
+	value, err := c.cached(ctx, "read", subscriptionTag(subscriptionId), fmt.Sprint(subscriptionId), func(ctx context.Context) (any, error) {
+		return c.next.Read(ctx, subscriptionId, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := new(model.Subscription)
+	if err := json.Unmarshal(value, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (c *SubscriptionCache) Update(ctx context.Context, subscription *model.Subscription) error {
+	before := c.current(ctx, subscription.ID)
+
+	if err := c.next.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, before, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) Delete(ctx context.Context, subscriptionId int64) error {
+	before := c.current(ctx, subscriptionId)
+
+	if err := c.next.Delete(ctx, subscriptionId); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, before)
+	return nil
+}
+
+func (c *SubscriptionCache) Restore(ctx context.Context, subscriptionId int64) error {
+	before := c.current(ctx, subscriptionId)
+
+	if err := c.next.Restore(ctx, subscriptionId); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, before)
+	return nil
+}
+
+func (c *SubscriptionCache) Pause(ctx context.Context, subscription *model.Subscription, from time.Time) error {
+	if err := c.next.Pause(ctx, subscription, from); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) Resume(ctx context.Context, subscription *model.Subscription, at time.Time) error {
+	if err := c.next.Resume(ctx, subscription, at); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) Cancel(ctx context.Context, subscription *model.Subscription, endDate time.Time) error {
+	if err := c.next.Cancel(ctx, subscription, endDate); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) AddDiscount(ctx context.Context, subscription *model.Subscription, discount *model.Discount) error {
+	if err := c.next.AddDiscount(ctx, subscription, discount); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) RemoveDiscount(ctx context.Context, subscription *model.Subscription, discountId int64) error {
+	if err := c.next.RemoveDiscount(ctx, subscription, discountId); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) Discounts(ctx context.Context, subscriptionId int64) ([]*model.Discount, error) {
+	return c.next.Discounts(ctx, subscriptionId)
+}
+
+func (c *SubscriptionCache) EffectivePrices(ctx context.Context, subscriptionId int64) ([]*model.PriceChange, error) {
+	return c.next.EffectivePrices(ctx, subscriptionId)
+}
+
+func (c *SubscriptionCache) AddMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error {
+	if err := c.next.AddMember(ctx, subscription, member); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) RemoveMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error {
+	if err := c.next.RemoveMember(ctx, subscription, member); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) Members(ctx context.Context, subscriptionId int64) ([]*model.Member, error) {
+	return c.next.Members(ctx, subscriptionId)
+}
+
+func (c *SubscriptionCache) SchedulePrice(ctx context.Context, subscription *model.Subscription, price *model.ScheduledPrice) error {
+	if err := c.next.SchedulePrice(ctx, subscription, price); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) RemovePrice(ctx context.Context, subscription *model.Subscription, priceId int64) error {
+	if err := c.next.RemovePrice(ctx, subscription, priceId); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, subscription)
+	return nil
+}
+
+func (c *SubscriptionCache) ScheduledPrices(ctx context.Context, subscriptionId int64) ([]*model.ScheduledPrice, error) {
+	return c.next.ScheduledPrices(ctx, subscriptionId)
+}
+
+func (c *SubscriptionCache) Purge(ctx context.Context, deletedBefore time.Time) ([]*model.Subscription, error) {
+	purged, err := c.next.Purge(ctx, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidate(ctx, purged...)
+	return purged, nil
+}
+
+func (c *SubscriptionCache) ConvertTrials(ctx context.Context, now time.Time) ([]*model.Subscription, error) {
+	converted, err := c.next.ConvertTrials(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidate(ctx, converted...)
+	return converted, nil
+}
+
+func (c *SubscriptionCache) EndSubscriptions(ctx context.Context, now time.Time) ([]*model.Subscription, error) {
+	ended, err := c.next.EndSubscriptions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidate(ctx, ended...)
+	return ended, nil
+}
+
+func (c *SubscriptionCache) List(ctx context.Context, filter *model.Filter) ([]*model.Subscription, error) {
+	return c.next.List(ctx, filter)
+}
+
+func (c *SubscriptionCache) Total(ctx context.Context, subscription *model.Subscription, filter *model.Filter) (int64, error) {
+	if filter.AsOf != nil || filter.IncludeDeleted || subscription.EndDate == nil {
+		return c.next.Total(ctx, subscription, filter)
+	}
+
+	key := fmt.Sprintf("%q:%q:%s:%s",
+		subscription.UserId,
+		subscription.ServiceName,
+		subscription.StartDate.Format(time.DateOnly),
+		subscription.EndDate.Format(time.DateOnly),
+	)
+	value, err := c.cached(ctx, "total", serviceTag(subscription.UserId, subscription.ServiceName), key, func(ctx context.Context) (any, error) {
+		return c.next.Total(ctx, subscription, filter)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := json.Unmarshal(value, &total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (c *SubscriptionCache) Totals(ctx context.Context, subscriptions []*model.Subscription) ([]int64, error) {
+	return c.next.Totals(ctx, subscriptions)
+}
+
+func (c *SubscriptionCache) History(ctx context.Context, subscriptionId int64, filter *model.AuditFilter) ([]*model.AuditRecord, error) {
+	return c.next.History(ctx, subscriptionId, filter)
+}
+
+// cached returns the encoded value of the operation under the generation of the tag, loading it once for all
+// concurrent callers on a miss. Store failures fall back to loading.
+func (c *SubscriptionCache) cached(ctx context.Context, operation, tag, key string, load func(ctx context.Context) (any, error)) ([]byte, error) {
+	generation, err := c.store.Generation(ctx, tag)
+	if err != nil {
+		logger.Error(err)
+		loaded, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(loaded)
+	}
+
+	key = fmt.Sprintf("%s:%s:%d", operation, key, generation)
+	if value, ok, err := c.store.Get(ctx, key); err != nil {
+		logger.Error(err)
+	} else if ok {
+		hit(operation)
+		return value, nil
+	}
+	miss(operation)
+
+	value, err, _ := c.group.Do(key, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+		loaded, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.store.Set(ctx, key, value, c.ttl); err != nil {
+			logger.Error(err)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]byte), nil
+}
+
+// current reads the subscription before a mutation to learn which keys it affects.
+func (c *SubscriptionCache) current(ctx context.Context, subscriptionId int64) *model.Subscription {
+	subscription, err := c.next.Read(ctx, subscriptionId, &model.Filter{IncludeDeleted: true})
+	if err != nil {
+		return &model.Subscription{ID: subscriptionId}
+	}
+
+	return subscription
+}
+
+// invalidate bumps the generations of the subscriptions, their owners and members and their user services, since
+// the totals of the members include their shares. A failure is only logged: the mutation is already committed, and
+// the TTL bounds how long stale values are served.
+func (c *SubscriptionCache) invalidate(ctx context.Context, subscriptions ...*model.Subscription) {
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	var tags []string
+	for _, subscription := range subscriptions {
+		tags = append(tags,
+			subscriptionTag(subscription.ID),
+			serviceTag(subscription.UserId, ""),
+			serviceTag(subscription.UserId, subscription.ServiceName),
+		)
+
+		members, err := c.next.Members(context.WithoutCancel(ctx), subscription.ID)
+		if err != nil {
+			logger.Error(err)
+		}
+		for _, member := range members {
+			tags = append(tags,
+				serviceTag(member.UserId, ""),
+				serviceTag(member.UserId, subscription.ServiceName),
+			)
+		}
+	}
+
+	if err := c.store.Bump(context.WithoutCancel(ctx), tags...); err != nil {
+		logger.Error(err)
+	}
+}
+
+func subscriptionTag(subscriptionId int64) string {
+	return fmt.Sprintf("generation:subscription:%d", subscriptionId)
+}
+
+// serviceTag is the tag of the subscriptions of the user to the service, or of all its subscriptions when
+// serviceName is empty.
+func serviceTag(userId, serviceName string) string {
+	return fmt.Sprintf("generation:user:%q:%q", userId, serviceName)
+}
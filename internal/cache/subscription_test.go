@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// fakeSubscriptions serves a single subscription shared with a member and counts the loads the cache lets through.
+// Every mutation succeeds and the bulk ones affect the subscription.
+type fakeSubscriptions struct {
+	Subscription
+	subscription *model.Subscription
+	members      []*model.Member
+	reads        int
+	totals       map[string]int
+}
+
+func (f *fakeSubscriptions) Read(_ context.Context, _ int64, filter *model.Filter) (*model.Subscription, error) {
+	if !filter.IncludeDeleted {
+		f.reads++
+	}
+	return f.subscription, nil
+}
+
+func (f *fakeSubscriptions) Total(_ context.Context, subscription *model.Subscription, _ *model.Filter) (int64, error) {
+	f.totals[subscription.UserId]++
+	return 100, nil
+}
+
+func (f *fakeSubscriptions) Members(context.Context, int64) ([]*model.Member, error) {
+	return f.members, nil
+}
+
+func (f *fakeSubscriptions) Create(context.Context, *model.Subscription) error { return nil }
+func (f *fakeSubscriptions) Update(context.Context, *model.Subscription) error { return nil }
+func (f *fakeSubscriptions) Delete(context.Context, int64) error               { return nil }
+func (f *fakeSubscriptions) Restore(context.Context, int64) error              { return nil }
+
+func (f *fakeSubscriptions) Pause(context.Context, *model.Subscription, time.Time) error  { return nil }
+func (f *fakeSubscriptions) Resume(context.Context, *model.Subscription, time.Time) error { return nil }
+func (f *fakeSubscriptions) Cancel(context.Context, *model.Subscription, time.Time) error { return nil }
+
+func (f *fakeSubscriptions) AddDiscount(context.Context, *model.Subscription, *model.Discount) error {
+	return nil
+}
+
+func (f *fakeSubscriptions) RemoveDiscount(context.Context, *model.Subscription, int64) error {
+	return nil
+}
+
+func (f *fakeSubscriptions) AddMember(context.Context, *model.Subscription, *model.Member) error {
+	return nil
+}
+
+func (f *fakeSubscriptions) RemoveMember(context.Context, *model.Subscription, *model.Member) error {
+	return nil
+}
+
+func (f *fakeSubscriptions) SchedulePrice(context.Context, *model.Subscription, *model.ScheduledPrice) error {
+	return nil
+}
+
+func (f *fakeSubscriptions) RemovePrice(context.Context, *model.Subscription, int64) error {
+	return nil
+}
+
+func (f *fakeSubscriptions) Purge(context.Context, time.Time) ([]*model.Subscription, error) {
+	return []*model.Subscription{f.subscription}, nil
+}
+
+func (f *fakeSubscriptions) ConvertTrials(context.Context, time.Time) ([]*model.Subscription, error) {
+	return []*model.Subscription{f.subscription}, nil
+}
+
+func (f *fakeSubscriptions) EndSubscriptions(context.Context, time.Time) ([]*model.Subscription, error) {
+	return []*model.Subscription{f.subscription}, nil
+}
+
+func TestSubscriptionCacheInvalidatesOnEveryMutation(t *testing.T) {
+	endDate := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+	subscription := &model.Subscription{
+		ID:          1,
+		ServiceName: "Netflix",
+		UserId:      "alice",
+		StartDate:   time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &endDate,
+	}
+	member := &model.Member{SubscriptionId: 1, UserId: "bob"}
+
+	mutations := map[string]func(ctx context.Context, c *SubscriptionCache) error{
+		"create": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Create(ctx, subscription)
+		},
+		"update": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Update(ctx, subscription)
+		},
+		"delete": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Delete(ctx, subscription.ID)
+		},
+		"restore": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Restore(ctx, subscription.ID)
+		},
+		"pause": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Pause(ctx, subscription, endDate)
+		},
+		"resume": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Resume(ctx, subscription, endDate)
+		},
+		"cancel": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.Cancel(ctx, subscription, endDate)
+		},
+		"add discount": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.AddDiscount(ctx, subscription, &model.Discount{})
+		},
+		"remove discount": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.RemoveDiscount(ctx, subscription, 1)
+		},
+		"add member": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.AddMember(ctx, subscription, member)
+		},
+		"remove member": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.RemoveMember(ctx, subscription, member)
+		},
+		"schedule price": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.SchedulePrice(ctx, subscription, &model.ScheduledPrice{})
+		},
+		"remove price": func(ctx context.Context, c *SubscriptionCache) error {
+			return c.RemovePrice(ctx, subscription, 1)
+		},
+		"purge": func(ctx context.Context, c *SubscriptionCache) error {
+			_, err := c.Purge(ctx, endDate)
+			return err
+		},
+		"convert trials": func(ctx context.Context, c *SubscriptionCache) error {
+			_, err := c.ConvertTrials(ctx, endDate)
+			return err
+		},
+		"end subscriptions": func(ctx context.Context, c *SubscriptionCache) error {
+			_, err := c.EndSubscriptions(ctx, endDate)
+			return err
+		},
+	}
+
+	for name, mutate := range mutations {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			next := &fakeSubscriptions{
+				subscription: subscription,
+				members:      []*model.Member{member},
+				totals:       make(map[string]int),
+			}
+			c := NewSubscription(next, NewLRU(100), time.Minute)
+
+			load := func() {
+				if _, err := c.Read(ctx, subscription.ID, &model.Filter{}); err != nil {
+					t.Fatal(err)
+				}
+				for _, userId := range []string{"alice", "bob"} {
+					query := &model.Subscription{
+						UserId:      userId,
+						ServiceName: subscription.ServiceName,
+						StartDate:   subscription.StartDate,
+						EndDate:     subscription.EndDate,
+					}
+					if _, err := c.Total(ctx, query, &model.Filter{}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			load()
+			load()
+			if next.reads != 1 || next.totals["alice"] != 1 || next.totals["bob"] != 1 {
+				t.Fatalf("got %d reads and totals %v before the mutation, want every value loaded once", next.reads, next.totals)
+			}
+
+			if err := mutate(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+
+			load()
+			if next.reads != 2 {
+				t.Errorf("got %d reads, want the subscription loaded again", next.reads)
+			}
+			if next.totals["alice"] != 2 {
+				t.Errorf("got %d totals of the owner, want the total loaded again", next.totals["alice"])
+			}
+			if next.totals["bob"] != 2 {
+				t.Errorf("got %d totals of the member, want the total loaded again", next.totals["bob"])
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Event interface {
+	Type() string
+	// SubscriptionId is 0 for the events that belong to no subscription.
+	SubscriptionId() int64
+	UserId() string
+	ServiceName() string
+}
+
+// Subscription is the subscription state carried by events, in the shape of the subscriptions row.
+type Subscription struct {
+	ID           int64      `json:"id"`
+	ServiceName  string     `json:"service_name"`
+	Price        int64      `json:"price"`
+	UserId       string     `json:"user_id"`
+	StartDate    string     `json:"start_date"`
+	EndDate      *string    `json:"end_date"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+	Status       string     `json:"status"`
+	TrialEndDate *string    `json:"trial_end_date"`
+}
+
+type SubscriptionCreated struct {
+	Subscription Subscription `json:"subscription"`
+}
+
+type SubscriptionUpdated struct {
+	Before Subscription `json:"before"`
+	After  Subscription `json:"after"`
+}
+
+type SubscriptionDeleted struct {
+	Subscription Subscription `json:"subscription"`
+}
+
+type SubscriptionEnded struct {
+	Subscription Subscription `json:"subscription"`
+	EndDate      string       `json:"end_date"`
+}
+
+type SubscriptionPriceChanged struct {
+	Subscription Subscription `json:"subscription"`
+	OldPrice     int64        `json:"old_price"`
+	NewPrice     int64        `json:"new_price"`
+}
+
+type SubscriptionPaused struct {
+	Subscription Subscription `json:"subscription"`
+}
+
+type SubscriptionResumed struct {
+	Subscription Subscription `json:"subscription"`
+}
+
+type SubscriptionCancelled struct {
+	Subscription Subscription `json:"subscription"`
+	EndDate      string       `json:"end_date"`
+}
+
+// TrialConverted is raised when a subscription leaves its trial and starts being billed.
+type TrialConverted struct {
+	Subscription Subscription `json:"subscription"`
+}
+
+// DiscountAdded is raised when a discount is attached to a subscription.
+type DiscountAdded struct {
+	Subscription Subscription   `json:"subscription"`
+	Discount     model.Discount `json:"discount"`
+}
+
+// DiscountRemoved is raised when a discount of a subscription stops being applied.
+type DiscountRemoved struct {
+	Subscription Subscription   `json:"subscription"`
+	Discount     model.Discount `json:"discount"`
+}
+
+// MemberAdded is raised when a subscription is shared with another user.
+type MemberAdded struct {
+	Subscription Subscription `json:"subscription"`
+	Member       model.Member `json:"member"`
+}
+
+// MemberRemoved is raised when a subscription stops being shared with a member.
+type MemberRemoved struct {
+	Subscription Subscription `json:"subscription"`
+	Member       model.Member `json:"member"`
+}
+
+// PriceScheduled is raised when a price is scheduled for a subscription from a month.
+type PriceScheduled struct {
+	Subscription Subscription         `json:"subscription"`
+	Price        model.ScheduledPrice `json:"scheduled_price"`
+}
+
+// PriceRemoved is raised when a scheduled price of a subscription stops being applied.
+type PriceRemoved struct {
+	Subscription Subscription         `json:"subscription"`
+	Price        model.ScheduledPrice `json:"scheduled_price"`
+}
+
+// BudgetThresholdCrossed is raised once per budget period when spending reaches a threshold of the budget.
+type BudgetThresholdCrossed struct {
+	Budget      model.Budget `json:"budget"`
+	Threshold   int32        `json:"threshold"`
+	PeriodStart string       `json:"period_start"`
+	Spent       int64        `json:"spent"`
+	PercentUsed float64      `json:"percent_used"`
+}
+
+func (e *SubscriptionCreated) Type() string          { return model.EventSubscriptionCreated }
+func (e *SubscriptionCreated) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionCreated) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionCreated) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *SubscriptionUpdated) Type() string          { return model.EventSubscriptionUpdated }
+func (e *SubscriptionUpdated) SubscriptionId() int64 { return e.After.ID }
+func (e *SubscriptionUpdated) UserId() string        { return e.After.UserId }
+func (e *SubscriptionUpdated) ServiceName() string   { return e.After.ServiceName }
+
+func (e *SubscriptionDeleted) Type() string          { return model.EventSubscriptionDeleted }
+func (e *SubscriptionDeleted) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionDeleted) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionDeleted) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *SubscriptionEnded) Type() string          { return model.EventSubscriptionEnded }
+func (e *SubscriptionEnded) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionEnded) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionEnded) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *SubscriptionPriceChanged) Type() string          { return model.EventSubscriptionPriceChanged }
+func (e *SubscriptionPriceChanged) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionPriceChanged) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionPriceChanged) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *SubscriptionPaused) Type() string          { return model.EventSubscriptionPaused }
+func (e *SubscriptionPaused) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionPaused) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionPaused) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *SubscriptionResumed) Type() string          { return model.EventSubscriptionResumed }
+func (e *SubscriptionResumed) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionResumed) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionResumed) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *SubscriptionCancelled) Type() string          { return model.EventSubscriptionCancelled }
+func (e *SubscriptionCancelled) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *SubscriptionCancelled) UserId() string        { return e.Subscription.UserId }
+func (e *SubscriptionCancelled) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *TrialConverted) Type() string          { return model.EventTrialConverted }
+func (e *TrialConverted) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *TrialConverted) UserId() string        { return e.Subscription.UserId }
+func (e *TrialConverted) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *DiscountAdded) Type() string          { return model.EventDiscountAdded }
+func (e *DiscountAdded) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *DiscountAdded) UserId() string        { return e.Subscription.UserId }
+func (e *DiscountAdded) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *DiscountRemoved) Type() string          { return model.EventDiscountRemoved }
+func (e *DiscountRemoved) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *DiscountRemoved) UserId() string        { return e.Subscription.UserId }
+func (e *DiscountRemoved) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *MemberAdded) Type() string          { return model.EventMemberAdded }
+func (e *MemberAdded) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *MemberAdded) UserId() string        { return e.Subscription.UserId }
+func (e *MemberAdded) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *MemberRemoved) Type() string          { return model.EventMemberRemoved }
+func (e *MemberRemoved) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *MemberRemoved) UserId() string        { return e.Subscription.UserId }
+func (e *MemberRemoved) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *PriceScheduled) Type() string          { return model.EventPriceScheduled }
+func (e *PriceScheduled) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *PriceScheduled) UserId() string        { return e.Subscription.UserId }
+func (e *PriceScheduled) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *PriceRemoved) Type() string          { return model.EventPriceRemoved }
+func (e *PriceRemoved) SubscriptionId() int64 { return e.Subscription.ID }
+func (e *PriceRemoved) UserId() string        { return e.Subscription.UserId }
+func (e *PriceRemoved) ServiceName() string   { return e.Subscription.ServiceName }
+
+func (e *BudgetThresholdCrossed) Type() string          { return model.EventBudgetThresholdCrossed }
+func (e *BudgetThresholdCrossed) SubscriptionId() int64 { return 0 }
+func (e *BudgetThresholdCrossed) UserId() string        { return e.Budget.UserId }
+func (e *BudgetThresholdCrossed) ServiceName() string   { return e.Budget.ServiceName }
+
+// Key is the ordering key of the event: events of a key are published in their order. The events of a subscription
+// share its key, budget alerts are keyed by their budget.
+func Key(e Event) string {
+	if alert, ok := e.(*BudgetThresholdCrossed); ok {
+		return fmt.Sprintf("budget:%d", alert.Budget.ID)
+	}
+
+	return fmt.Sprintf("subscription:%d", e.SubscriptionId())
+}
+
+// Envelope is an event as stored in the outbox and handed to publishers. SubscriptionId is nil for the events that
+// belong to no subscription.
+type Envelope struct {
+	ID             int64           `json:"id"`
+	Type           string          `json:"type"`
+	Key            string          `json:"key"`
+	SubscriptionId *int64          `json:"subscription_id"`
+	UserId         string          `json:"user_id"`
+	ServiceName    string          `json:"service_name"`
+	Payload        json.RawMessage `json:"payload"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+}
+
+type Filter struct {
+	UserId      string
+	ServiceName string
+}
+
+// Match reports whether the event is a change of a subscription selected by the filter.
+func (f *Filter) Match(envelope *Envelope) bool {
+	return envelope.SubscriptionId != nil &&
+		(f.UserId == "" || f.UserId == envelope.UserId) &&
+		(f.ServiceName == "" || f.ServiceName == envelope.ServiceName)
+}
+
+// FromChange derives the domain events of a subscription mutation from its before and after row snapshots.
+func FromChange(action string, before, after []byte) ([]Event, error) {
+	var previous, current *Subscription
+	if before != nil {
+		previous = new(Subscription)
+		if err := json.Unmarshal(before, previous); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		current = new(Subscription)
+		if err := json.Unmarshal(after, current); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []Event
+	switch action {
+	case model.ActionCreate:
+		result = append(result, &SubscriptionCreated{Subscription: *current})
+	case model.ActionUpdate, model.ActionRestore:
+		result = append(result, &SubscriptionUpdated{Before: *previous, After: *current})
+		if previous.Price != current.Price {
+			result = append(result, &SubscriptionPriceChanged{
+				Subscription: *current,
+				OldPrice:     previous.Price,
+				NewPrice:     current.Price,
+			})
+		}
+		if action == model.ActionUpdate && current.ended() && !previous.ended() {
+			result = append(result, &SubscriptionEnded{Subscription: *current, EndDate: *current.EndDate})
+		}
+	case model.ActionDelete:
+		result = append(result, &SubscriptionDeleted{Subscription: *current})
+	case model.ActionEnd:
+		result = append(result, &SubscriptionEnded{Subscription: *current, EndDate: *current.EndDate})
+	case model.ActionAddDiscount, model.ActionRemoveDiscount, model.ActionAddMember, model.ActionRemoveMember,
+		model.ActionSchedulePrice, model.ActionRemovePrice:
+		changed := new(adjustment)
+		if err := json.Unmarshal(after, changed); err != nil {
+			return nil, err
+		}
+		switch action {
+		case model.ActionAddDiscount:
+			result = append(result, &DiscountAdded{Subscription: *current, Discount: changed.Discount})
+		case model.ActionRemoveDiscount:
+			result = append(result, &DiscountRemoved{Subscription: *current, Discount: changed.Discount})
+		case model.ActionAddMember:
+			result = append(result, &MemberAdded{Subscription: *current, Member: changed.Member})
+		case model.ActionRemoveMember:
+			result = append(result, &MemberRemoved{Subscription: *current, Member: changed.Member})
+		case model.ActionSchedulePrice:
+			result = append(result, &PriceScheduled{Subscription: *current, Price: changed.Price})
+		case model.ActionRemovePrice:
+			result = append(result, &PriceRemoved{Subscription: *current, Price: changed.Price})
+		}
+	case model.ActionPause:
+		result = append(result, &SubscriptionPaused{Subscription: *current})
+	case model.ActionResume:
+		result = append(result, &SubscriptionResumed{Subscription: *current})
+	case model.ActionCancel:
+		result = append(result, &SubscriptionCancelled{Subscription: *current, EndDate: *current.EndDate})
+		if current.ended() && !previous.ended() {
+			result = append(result, &SubscriptionEnded{Subscription: *current, EndDate: *current.EndDate})
+		}
+	}
+
+	if previous != nil && current != nil && previous.Status == model.StatusTrial &&
+		(current.Status == model.StatusActive || current.Status == model.StatusPaused) {
+		result = append(result, &TrialConverted{Subscription: *current})
+	}
+
+	return result, nil
+}
+
+// adjustment is the discount, member or scheduled price changed by an adjustment of a subscription, which the
+// snapshot after it carries next to the subscription.
+type adjustment struct {
+	Discount model.Discount       `json:"discount"`
+	Member   model.Member         `json:"member"`
+	Price    model.ScheduledPrice `json:"scheduled_price"`
+}
+
+func (s *Subscription) ended() bool {
+	if s.EndDate == nil {
+		return false
+	}
+
+	endDate, err := time.Parse(time.DateOnly, *s.EndDate)
+	if err != nil {
+		return false
+	}
+
+	return !endDate.After(time.Now())
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, envelope *Envelope) error
+	Close() error
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// maxFirst bounds the page of a connection.
+	maxFirst = 100
+	// listComplexity is the number of items a list without arguments is assumed to hold when the complexity of a
+	// query is estimated.
+	listComplexity = 10
+)
+
+// NewHandler serves GraphQL queries over the subscription and budget services. Queries nested deeper than maxDepth
+// or estimated to cost more than maxComplexity are rejected before they run.
+func NewHandler(subscriptionService Subscription, budgetService Budget, maxDepth, maxComplexity int) http.Handler {
+	config := Config{Resolvers: &Resolver{
+		subscriptionService: subscriptionService,
+		budgetService:       budgetService,
+	}}
+	config.Complexity.Query.Users = func(childComplexity int, ids []string) int {
+		return len(ids) * childComplexity
+	}
+	config.Complexity.Query.Subscriptions = func(childComplexity int, _ *SubscriptionFilter, first int, _ *string) int {
+		return min(first, maxFirst) * childComplexity
+	}
+	config.Complexity.User.Subscriptions = func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+	config.Complexity.User.Services = func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+	config.Complexity.User.Budgets = func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+	config.Complexity.Service.Subscriptions = func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+
+	srv := handler.New(NewExecutableSchema(config))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(depthLimit(maxDepth))
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+
+	return withLoaders(subscriptionService, budgetService, srv)
+}
+
+// depthLimit rejects operations that nest fields deeper than the limit. Introspection fields are not counted, so
+// that tools can read the schema.
+type depthLimit int
+
+func (l depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (l depthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l depthLimit) MutateOperationContext(_ context.Context, operation *graphql.OperationContext) *gqlerror.Error {
+	if d := depth(operation.Operation.SelectionSet); d > int(l) {
+		return gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", d, l)
+	}
+
+	return nil
+}
+
+func depth(selectionSet ast.SelectionSet) int {
+	deepest := 0
+	for _, selection := range selectionSet {
+		d := 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if !strings.HasPrefix(selection.Name, "__") {
+				d = 1 + depth(selection.SelectionSet)
+			}
+		case *ast.InlineFragment:
+			d = depth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				d = depth(selection.Definition.SelectionSet)
+			}
+		}
+		deepest = max(deepest, d)
+	}
+
+	return deepest
+}
+
+func (f *SubscriptionFilter) model(limit int, afterId int64) *model.Filter {
+	filter := &model.Filter{Limit: limit, AfterId: afterId}
+	if f != nil {
+		if f.UserID != nil {
+			filter.UserId = *f.UserID
+		}
+		filter.IncludeDeleted = f.IncludeDeleted
+		filter.AsOf = f.AsOf
+	}
+
+	return filter
+}
+
+// Cursors are opaque to clients; they encode the ID of the subscription the page continues after.
+func encodeCursor(subscriptionId int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(subscriptionId, 10)))
+}
+
+func decodeCursor(cursor *string) (int64, error) {
+	if cursor == nil {
+		return 0, nil
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(*cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(string(id), 10, 64)
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
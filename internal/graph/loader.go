@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/vikstrous/dataloadgen"
+)
+
+// loaderWait is how long a loader collects the keys requested by sibling fields before it loads them in one call.
+const loaderWait = time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the reads of one request, so a list of users reads their subscriptions in one query instead of one
+// per user. A key the caller may not read fails alone, the other keys of its batch are still loaded.
+type loaders struct {
+	subscriptionsByUser *dataloadgen.Loader[string, []*model.ExternalData]
+	budgetsByUser       *dataloadgen.Loader[string, []*model.BudgetStatus]
+	totals              *dataloadgen.Loader[totalKey, int64]
+}
+
+// totalKey is the range of the subscriptions of a user to a service whose total is loaded.
+type totalKey struct {
+	userId      string
+	serviceName string
+	startDate   string
+	endDate     string
+}
+
+func newLoaders(subscriptionService Subscription, budgetService Budget) *loaders {
+	return &loaders{
+		subscriptionsByUser: dataloadgen.NewLoader(subscriptionService.ListOf, dataloadgen.WithWait(loaderWait)),
+		budgetsByUser:       dataloadgen.NewLoader(budgetService.StatusOf, dataloadgen.WithWait(loaderWait)),
+		totals: dataloadgen.NewLoader(func(ctx context.Context, keys []totalKey) ([]int64, []error) {
+			data := make([]*model.ExternalData, len(keys))
+			for i, key := range keys {
+				data[i] = &model.ExternalData{
+					UserId:      key.userId,
+					ServiceName: key.serviceName,
+					StartDate:   key.startDate,
+					EndDate:     key.endDate,
+				}
+			}
+
+			return subscriptionService.Totals(ctx, data)
+		}, dataloadgen.WithWait(loaderWait)),
+	}
+}
+
+func withLoaders(subscriptionService Subscription, budgetService Budget, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(subscriptionService, budgetService))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
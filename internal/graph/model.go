@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type User struct {
+	ID string
+}
+
+// Service is a service a user is subscribed to, with the user's subscriptions to it.
+type Service struct {
+	UserId        string
+	Name          string
+	Subscriptions []*model.ExternalData
+}
+
+// MarshalInt64ID writes the int64 IDs of the model as GraphQL IDs, which are strings on the wire.
+func MarshalInt64ID(id int64) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(strconv.FormatInt(id, 10)))
+	})
+}
+
+func UnmarshalInt64ID(v any) (int64, error) {
+	switch v := v.(type) {
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("%T is not an ID", v)
+	}
+}
@@ -0,0 +1,33 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graph
+
+import (
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
+type Query struct {
+}
+
+type SubscriptionConnection struct {
+	Edges    []*SubscriptionEdge `json:"edges"`
+	PageInfo *PageInfo           `json:"pageInfo"`
+}
+
+type SubscriptionEdge struct {
+	Cursor string              `json:"cursor"`
+	Node   *model.ExternalData `json:"node"`
+}
+
+type SubscriptionFilter struct {
+	UserID         *string    `json:"userId,omitempty"`
+	IncludeDeleted bool       `json:"includeDeleted"`
+	AsOf           *time.Time `json:"asOf,omitempty"`
+}
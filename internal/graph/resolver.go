@@ -0,0 +1,24 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Subscription interface {
+	Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.SubscriptionDetail, error)
+	List(ctx context.Context, filter *model.Filter) ([]*model.ExternalData, error)
+	ListOf(ctx context.Context, userIds []string) ([][]*model.ExternalData, []error)
+	Total(ctx context.Context, data *model.ExternalData, filter *model.Filter) (int64, error)
+	Totals(ctx context.Context, data []*model.ExternalData) ([]int64, []error)
+}
+
+type Budget interface {
+	StatusOf(ctx context.Context, userIds []string) ([][]*model.BudgetStatus, []error)
+}
+
+type Resolver struct {
+	subscriptionService Subscription
+	budgetService       Budget
+}
@@ -0,0 +1,181 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*User, error) {
+	return &User{ID: id}, nil
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, ids []string) ([]*User, error) {
+	users := make([]*User, len(ids))
+	for i, id := range ids {
+		users[i] = &User{ID: id}
+	}
+
+	return users, nil
+}
+
+// Subscription is the resolver for the subscription field.
+func (r *queryResolver) Subscription(ctx context.Context, id string, includeDeleted bool, asOf *time.Time) (*model.ExternalData, error) {
+	subscriptionId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := r.subscriptionService.Read(ctx, subscriptionId, &model.Filter{
+		IncludeDeleted: includeDeleted,
+		AsOf:           asOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &detail.ExternalData, nil
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *queryResolver) Subscriptions(ctx context.Context, filter *SubscriptionFilter, first int, after *string) (*SubscriptionConnection, error) {
+	if first < 1 {
+		return nil, err_msg.InvalidPage
+	}
+	first = min(first, maxFirst)
+
+	afterId, err := decodeCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more than the page tells whether there is a next one.
+	list, err := r.subscriptionService.List(ctx, filter.model(first+1, afterId))
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &SubscriptionConnection{
+		Edges:    make([]*SubscriptionEdge, 0, first),
+		PageInfo: &PageInfo{HasNextPage: len(list) > first},
+	}
+	for _, subscription := range list[:min(first, len(list))] {
+		cursor := encodeCursor(subscription.ID)
+		connection.Edges = append(connection.Edges, &SubscriptionEdge{Cursor: cursor, Node: subscription})
+		connection.PageInfo.EndCursor = &cursor
+	}
+
+	return connection, nil
+}
+
+// Total is the resolver for the total field.
+func (r *queryResolver) Total(ctx context.Context, userID string, serviceName string, startDate string, endDate string, includeDeleted bool, asOf *time.Time) (int, error) {
+	total, err := r.subscriptionService.Total(ctx, &model.ExternalData{
+		UserId:      userID,
+		ServiceName: serviceName,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}, &model.Filter{
+		IncludeDeleted: includeDeleted,
+		AsOf:           asOf,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
+// Total is the resolver for the total field.
+func (r *serviceResolver) Total(ctx context.Context, obj *Service, startDate string, endDate string) (int, error) {
+	total, err := loadersFrom(ctx).totals.Load(ctx, totalKey{
+		userId:      obj.UserId,
+		serviceName: obj.Name,
+		startDate:   startDate,
+		endDate:     endDate,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
+// User is the resolver for the user field.
+func (r *subscriptionResolver) User(ctx context.Context, obj *model.ExternalData) (*User, error) {
+	return &User{ID: obj.UserId}, nil
+}
+
+// EndDate is the resolver for the endDate field.
+func (r *subscriptionResolver) EndDate(ctx context.Context, obj *model.ExternalData) (*string, error) {
+	return optional(obj.EndDate), nil
+}
+
+// DeletedAt is the resolver for the deletedAt field.
+func (r *subscriptionResolver) DeletedAt(ctx context.Context, obj *model.ExternalData) (*string, error) {
+	return optional(obj.DeletedAt), nil
+}
+
+// TrialEndDate is the resolver for the trialEndDate field.
+func (r *subscriptionResolver) TrialEndDate(ctx context.Context, obj *model.ExternalData) (*string, error) {
+	return optional(obj.TrialEndDate), nil
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *userResolver) Subscriptions(ctx context.Context, obj *User) ([]*model.ExternalData, error) {
+	return loadersFrom(ctx).subscriptionsByUser.Load(ctx, obj.ID)
+}
+
+// Services is the resolver for the services field.
+func (r *userResolver) Services(ctx context.Context, obj *User) ([]*Service, error) {
+	subscriptions, err := loadersFrom(ctx).subscriptionsByUser.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []*Service
+	for _, subscription := range subscriptions {
+		i := slices.IndexFunc(services, func(service *Service) bool {
+			return service.Name == subscription.ServiceName
+		})
+		if i < 0 {
+			i = len(services)
+			services = append(services, &Service{UserId: obj.ID, Name: subscription.ServiceName})
+		}
+		services[i].Subscriptions = append(services[i].Subscriptions, subscription)
+	}
+
+	return services, nil
+}
+
+// Budgets is the resolver for the budgets field.
+func (r *userResolver) Budgets(ctx context.Context, obj *User) ([]*model.BudgetStatus, error) {
+	return loadersFrom(ctx).budgetsByUser.Load(ctx, obj.ID)
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Service returns ServiceResolver implementation.
+func (r *Resolver) Service() ServiceResolver { return &serviceResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type serviceResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
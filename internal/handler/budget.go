@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Budget interface {
+	Create(ctx context.Context, userId string, budget *model.Budget) error
+	Read(ctx context.Context, userId string, budgetId int64) (*model.Budget, error)
+	Update(ctx context.Context, userId string, budgetId int64, budget *model.Budget) error
+	Delete(ctx context.Context, userId string, budgetId int64) error
+	List(ctx context.Context, userId string) ([]*model.Budget, error)
+	Status(ctx context.Context, userId string) ([]*model.BudgetStatus, error)
+}
+
+type BudgetHandler struct {
+	budgetService Budget
+}
+
+func NewBudgetHandler(budgetService Budget) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+	}
+}
+
+func (h *BudgetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+
+	budget := new(model.Budget)
+	if err := json.NewDecoder(r.Body).Decode(budget); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.budgetService.Create(r.Context(), userId, budget); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *BudgetHandler) Read(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	id, err := strconv.ParseInt(r.PathValue("budget_id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	budget, err := h.budgetService.Read(r.Context(), userId, id)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *BudgetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	id, err := strconv.ParseInt(r.PathValue("budget_id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	budget := new(model.Budget)
+	if err := json.NewDecoder(r.Body).Decode(budget); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.budgetService.Update(r.Context(), userId, id, budget); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+	id, err := strconv.ParseInt(r.PathValue("budget_id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.budgetService.Delete(r.Context(), userId, id); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BudgetHandler) List(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+
+	list, err := h.budgetService.List(r.Context(), userId)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *BudgetHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+
+	list, err := h.budgetService.Status(r.Context(), userId)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
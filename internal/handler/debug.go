@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+type Debug interface {
+	Authorize(ctx context.Context) error
+}
+
+type DebugHandler struct {
+	debugService Debug
+}
+
+func NewDebugHandler(debugService Debug) *DebugHandler {
+	return &DebugHandler{
+		debugService: debugService,
+	}
+}
+
+// Vars serves the expvar metrics of the process to the actors allowed to read them.
+func (h *DebugHandler) Vars(w http.ResponseWriter, r *http.Request) {
+	if err := h.debugService.Authorize(r.Context()); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	expvar.Handler().ServeHTTP(w, r)
+}
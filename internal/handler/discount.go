@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+func (h *SubscriptionHandler) AddDiscount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	discount := new(model.Discount)
+	if err := json.NewDecoder(r.Body).Decode(discount); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if discount.StartDate, err = h.representation.month(discount.StartDate); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.AddDiscount(r.Context(), id, discount); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	discount.StartDate = h.representation.encodeMonth(discount.StartDate)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(discount); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *SubscriptionHandler) RemoveDiscount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	discountId, err := strconv.ParseInt(r.PathValue("discount_id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.RemoveDiscount(r.Context(), id, discountId); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) Discounts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.subscriptionService.Discounts(r.Context(), id)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, discount := range list {
+		discount.StartDate = h.representation.encodeMonth(discount.StartDate)
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
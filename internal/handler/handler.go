@@ -3,45 +3,100 @@ package handler
 import (
 	"net/http"
 
-	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/middleware"
+	"github.com/oatsmoke/20250905/internal/lib/request_id"
 )
 
 type Handler struct {
 	subscriptionHandler *SubscriptionHandler
+	webhookHandler      *WebhookHandler
+	streamHandler       *StreamHandler
+	preferenceHandler   *PreferenceHandler
+	budgetHandler       *BudgetHandler
+	reportHandler       *ReportHandler
+	debugHandler        *DebugHandler
+	graphQLHandler      http.Handler
+	lifecycles          map[string]Lifecycle
+	corsOrigins         []string
 }
 
-func New(subscriptionService Subscription) *Handler {
+func New(
+	subscriptionService Subscription,
+	webhookService Webhook,
+	streamService Stream,
+	preferenceService Preference,
+	budgetService Budget,
+	reportService Report,
+	debugService Debug,
+	graphQLHandler http.Handler,
+	lifecycles map[string]Lifecycle,
+	corsOrigins []string,
+) *Handler {
 	return &Handler{
 		subscriptionHandler: NewSubscriptionHandler(subscriptionService),
+		webhookHandler:      NewWebhookHandler(webhookService),
+		streamHandler:       NewStreamHandler(streamService),
+		preferenceHandler:   NewPreferenceHandler(preferenceService),
+		budgetHandler:       NewBudgetHandler(budgetService),
+		reportHandler:       NewReportHandler(reportService),
+		debugHandler:        NewDebugHandler(debugService),
+		graphQLHandler:      graphQLHandler,
+		lifecycles:          lifecycles,
+		corsOrigins:         corsOrigins,
 	}
 }
 
+// InitRoutes serves each API version under its prefix, /v1 and /v2, and the paths without a prefix as v1. Every route
+// group is wrapped in its own middleware chain.
 func (h *Handler) InitRoutes() http.Handler {
+	base := middleware.New(middleware.Recovery, request_id.Middleware, middleware.Logging)
+	api := base.Append(auth.Middleware, middleware.CORS(h.corsOrigins))
+
+	mux := http.NewServeMux()
+	for _, v := range versions {
+		mux.Handle(v.prefix()+"/", api.Then(http.StripPrefix(v.prefix(), h.versionRoutes(v, v.name))))
+	}
+	mux.Handle("/", api.Then(h.versionRoutes(versions[0], unversioned)))
+	mux.Handle("/graphql", api.Then(h.graphQLHandler))
+	mux.Handle("/debug/vars", base.Append(auth.Middleware).Then(http.HandlerFunc(h.debugHandler.Vars)))
+
+	return mux
+}
+
+// versionRoutes are the routes of an API version, without its prefix, with the validation of its operations and its
+// lifecycle headers. The requests are counted under metric.
+func (h *Handler) versionRoutes(v *version, metric string) http.Handler {
+	return middleware.New(v.announce(metric, h.lifecycles[v.name]), validate(v.operations)).Then(h.routes(v))
+}
+
+// handlers are the handlers of the operations of an API version.
+type handlers struct {
+	subscription *SubscriptionHandler
+	webhook      *WebhookHandler
+	stream       *StreamHandler
+	preference   *PreferenceHandler
+	budget       *BudgetHandler
+	report       *ReportHandler
+	version      *version
+}
+
+// routes serve the operations of the version, so that every route is described by its OpenAPI document.
+func (h *Handler) routes(v *version) http.Handler {
+	handlers := &handlers{
+		subscription: h.subscriptionHandler.in(v),
+		webhook:      h.webhookHandler,
+		stream:       h.streamHandler,
+		preference:   h.preferenceHandler,
+		budget:       h.budgetHandler,
+		report:       h.reportHandler.in(v),
+		version:      v,
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			h.subscriptionHandler.Create(w, r)
-		case http.MethodGet:
-			h.subscriptionHandler.List(w, r)
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
-		}
-	})
-	mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			h.subscriptionHandler.Read(w, r)
-		case http.MethodPut:
-			h.subscriptionHandler.Update(w, r)
-		case http.MethodDelete:
-			h.subscriptionHandler.Delete(w, r)
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
-		}
-	})
-	mux.HandleFunc("/subscriptions/total", h.subscriptionHandler.Total)
-	mux.HandleFunc("/swagger/", httpSwagger.WrapHandler)
+	for _, op := range v.operations {
+		mux.HandleFunc(op.method+" "+op.path, op.handler(handlers))
+	}
 
 	return mux
 }
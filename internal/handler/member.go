@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+func (h *SubscriptionHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	member := new(model.Member)
+	if err := json.NewDecoder(r.Body).Decode(member); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if member.StartDate, err = h.representation.month(member.StartDate); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+	if member.EndDate, err = h.representation.month(member.EndDate); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.AddMember(r.Context(), id, member); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	h.encodeMember(member)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(member); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *SubscriptionHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	memberId, err := strconv.ParseInt(r.PathValue("member_id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	from, err := h.representation.month(r.URL.Query().Get("from"))
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.RemoveMember(r.Context(), id, memberId, from); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) Members(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.subscriptionService.Members(r.Context(), id)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, member := range list {
+		h.encodeMember(member)
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// encodeMember converts the months of the member to the representation of the version.
+func (h *SubscriptionHandler) encodeMember(member *model.Member) {
+	member.StartDate = h.representation.encodeMonth(member.StartDate)
+	member.EndDate = h.representation.encodeMonth(member.EndDate)
+}
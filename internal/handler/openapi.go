@@ -0,0 +1,761 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// Parameter formats beyond those of JSON Schema.
+const (
+	formatMonth    = "month"
+	formatISOMonth = "iso-month"
+	formatDuration = "duration"
+)
+
+// param is a path, query or header parameter of an operation.
+type param struct {
+	name        string
+	in          string
+	kind        string
+	format      string
+	enum        []string
+	required    bool
+	description string
+}
+
+// operation describes an endpoint. The OpenAPI document and the request validation are both built from operations,
+// with the schemas of the request and response bodies taken from their Go types.
+type operation struct {
+	// version is the API version of an operation that replaces the one with the same method and path in that version.
+	// Operations without one belong to every version.
+	version     string
+	method      string
+	path        string
+	tag         string
+	summary     string
+	description string
+	params      []param
+	body        any
+	status      int
+	response    any
+	// statuses are the responses besides the success and the 401, 403 and 500 every operation may return.
+	statuses []int
+	// handler selects the handler that serves the operation.
+	handler func(h *handlers) http.HandlerFunc
+}
+
+var (
+	subscriptionId = param{name: "id", in: "path", kind: "integer", format: "int64", required: true, description: "subscription ID"}
+	webhookId      = param{name: "id", in: "path", kind: "integer", format: "int64", required: true, description: "webhook ID"}
+	userId         = param{name: "id", in: "path", kind: "string", required: true, description: "user ID"}
+	budgetId       = param{name: "budget_id", in: "path", kind: "integer", format: "int64", required: true, description: "budget ID"}
+	includeDeleted = param{name: "include_deleted", in: "query", kind: "boolean", description: "include deleted subscriptions"}
+	asOf           = param{name: "as_of", in: "query", kind: "string", format: "date-time", description: "state at time"}
+	monthRange     = []param{
+		{name: "from", in: "query", kind: "string", format: formatMonth, required: true, description: "first month"},
+		{name: "to", in: "query", kind: "string", format: formatMonth, required: true, description: "last month"},
+	}
+)
+
+var operations = []operation{
+	{
+		method: http.MethodPost, path: "/subscriptions", tag: "subscription", summary: "Create subscription",
+		body: model.ExternalData{}, status: http.StatusCreated, statuses: []int{http.StatusBadRequest, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Create },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions", tag: "subscription", summary: "List subscriptions",
+		params: []param{
+			{name: "user_id", in: "query", kind: "string", description: "user ID"},
+			includeDeleted,
+			asOf,
+			{name: "trial_ends_within", in: "query", kind: "string", format: formatDuration, description: "only trials ending within the duration, e.g. 168h"},
+			{name: "limit", in: "query", kind: "integer", description: "page size"},
+			{name: "after_id", in: "query", kind: "integer", format: "int64", description: "only subscriptions with a greater ID, the last ID of the previous page"},
+		},
+		status: http.StatusOK, response: []model.ExternalData{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.List },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/total", tag: "subscription", summary: "Total subscriptions",
+		params: []param{
+			{name: "user_id", in: "query", kind: "string", required: true, description: "user ID"},
+			{name: "service_name", in: "query", kind: "string", required: true, description: "service name"},
+			{name: "start_date", in: "query", kind: "string", format: formatMonth, required: true, description: "first month"},
+			{name: "end_date", in: "query", kind: "string", format: formatMonth, required: true, description: "last month"},
+			includeDeleted,
+			asOf,
+		},
+		status: http.StatusOK, response: int64(0), statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Total },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/stream", tag: "subscription", summary: "Stream subscription changes",
+		description: `Server-Sent Events with the event id as "id" and the event type as "event". Resume with the Last-Event-ID header.`,
+		params: []param{
+			{name: "user_id", in: "query", kind: "string", description: "user ID"},
+			{name: "service_name", in: "query", kind: "string", description: "service name"},
+			{name: "Last-Event-ID", in: "header", kind: "integer", format: "int64", description: "last received event id"},
+		},
+		status: http.StatusOK, response: events.Envelope{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.stream.Stream },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}", tag: "subscription", summary: "Read subscription",
+		description: "The current state of a live subscription lists the price billed from each month it changes.",
+		params:      []param{subscriptionId, includeDeleted, asOf},
+		status:      http.StatusOK, response: model.SubscriptionDetail{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Read },
+	},
+	{
+		method: http.MethodPut, path: "/subscriptions/{id}", tag: "subscription", summary: "Update subscription",
+		params: []param{subscriptionId}, body: model.ExternalData{},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Update },
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}", tag: "subscription", summary: "Delete subscription",
+		params: []param{subscriptionId},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Delete },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/history", tag: "subscription", summary: "History of subscription changes",
+		params: []param{
+			subscriptionId,
+			{name: "actor", in: "query", kind: "string", description: "actor"},
+			{name: "from", in: "query", kind: "string", format: "date-time", description: "from time"},
+			{name: "to", in: "query", kind: "string", format: "date-time", description: "to time"},
+		},
+		status: http.StatusOK, response: []model.AuditRecord{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.History },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/restore", tag: "subscription", summary: "Restore deleted subscription",
+		params: []param{subscriptionId},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Restore },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/pause", tag: "subscription", summary: "Pause subscription",
+		description: "Stops billing from the next month until the subscription is resumed.",
+		params:      []param{subscriptionId},
+		status:      http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Pause },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/resume", tag: "subscription", summary: "Resume subscription",
+		description: "Bills a paused subscription again from the current month.",
+		params:      []param{subscriptionId},
+		status:      http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Resume },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/cancel", tag: "subscription", summary: "Cancel subscription",
+		description: "Ends the subscription now, leaving the current month unbilled, or at the end of the current month; it stays cancelled after its end date.",
+		params: []param{
+			subscriptionId,
+			{name: "effective", in: "query", kind: "string", enum: []string{model.CancelNow, model.CancelPeriodEnd}, description: "period_end by default"},
+		},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Cancel },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/discounts", tag: "subscription", summary: "Add discount",
+		description: "Reduces the price by value percent or by value for periods months from start_date, skipping the months that are not billed.",
+		params:      []param{subscriptionId}, body: model.Discount{},
+		status: http.StatusCreated, response: model.Discount{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.AddDiscount },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/discounts", tag: "subscription", summary: "List discounts",
+		params: []param{subscriptionId},
+		status: http.StatusOK, response: []model.Discount{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Discounts },
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}/discounts/{discount_id}", tag: "subscription", summary: "Remove discount",
+		params: []param{
+			subscriptionId,
+			{name: "discount_id", in: "path", kind: "integer", format: "int64", required: true, description: "discount ID"},
+		},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.RemoveDiscount },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/members", tag: "subscription", summary: "Add member",
+		description: "Shares the cost of the subscription with another user from start_date. A member with share pays that percent of the price, the rest is split equally between the owner and the members without share.",
+		params:      []param{subscriptionId}, body: model.Member{},
+		status: http.StatusCreated, response: model.Member{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.AddMember },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/members", tag: "subscription", summary: "List members",
+		params: []param{subscriptionId},
+		status: http.StatusOK, response: []model.Member{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Members },
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}/members/{member_id}", tag: "subscription", summary: "Remove member",
+		params: []param{
+			subscriptionId,
+			{name: "member_id", in: "path", kind: "integer", format: "int64", required: true, description: "member ID"},
+			{name: "from", in: "query", kind: "string", format: formatMonth, description: "first month without the member, next month by default"},
+		},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.RemoveMember },
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/prices", tag: "subscription", summary: "Schedule price",
+		description: "Projects the subscription at price in the spending forecast from start_date until the next scheduled price, skipping the months that are not billed.",
+		params:      []param{subscriptionId}, body: model.ScheduledPrice{},
+		status: http.StatusCreated, response: model.ScheduledPrice{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.SchedulePrice },
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/prices", tag: "subscription", summary: "List scheduled prices",
+		params: []param{subscriptionId},
+		status: http.StatusOK, response: []model.ScheduledPrice{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.ScheduledPrices },
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}/prices/{price_id}", tag: "subscription", summary: "Remove scheduled price",
+		params: []param{
+			subscriptionId,
+			{name: "price_id", in: "path", kind: "integer", format: "int64", required: true, description: "scheduled price ID"},
+		},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.RemovePrice },
+	},
+	{
+		method: http.MethodPost, path: "/webhooks", tag: "webhook", summary: "Create webhook",
+		body:   model.Webhook{},
+		status: http.StatusCreated, response: model.Webhook{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.Create },
+	},
+	{
+		method: http.MethodGet, path: "/webhooks", tag: "webhook", summary: "List webhooks",
+		status: http.StatusOK, response: []model.Webhook{},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.List },
+	},
+	{
+		method: http.MethodGet, path: "/webhooks/{id}", tag: "webhook", summary: "Read webhook",
+		params: []param{webhookId},
+		status: http.StatusOK, response: model.Webhook{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.Read },
+	},
+	{
+		method: http.MethodPut, path: "/webhooks/{id}", tag: "webhook", summary: "Update webhook",
+		description: "An empty secret keeps the current one.",
+		params:      []param{webhookId}, body: model.Webhook{},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.Update },
+	},
+	{
+		method: http.MethodDelete, path: "/webhooks/{id}", tag: "webhook", summary: "Delete webhook",
+		params: []param{webhookId},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.Delete },
+	},
+	{
+		method: http.MethodGet, path: "/webhooks/{id}/deliveries", tag: "webhook", summary: "List webhook deliveries",
+		params: []param{
+			webhookId,
+			{name: "status", in: "query", kind: "string", enum: []string{model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead}, description: "delivery status"},
+		},
+		status: http.StatusOK, response: []model.WebhookDelivery{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.Deliveries },
+	},
+	{
+		method: http.MethodPost, path: "/webhooks/{id}/deliveries/{delivery_id}/redeliver", tag: "webhook", summary: "Redeliver webhook delivery",
+		params: []param{
+			webhookId,
+			{name: "delivery_id", in: "path", kind: "integer", format: "int64", required: true, description: "delivery ID"},
+		},
+		status: http.StatusAccepted, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.webhook.Redeliver },
+	},
+	{
+		method: http.MethodGet, path: "/users/{id}/notification-preferences", tag: "user", summary: "Read notification preferences",
+		params: []param{userId},
+		status: http.StatusOK, response: model.Preference{},
+		handler: func(h *handlers) http.HandlerFunc { return h.preference.Read },
+	},
+	{
+		method: http.MethodPut, path: "/users/{id}/notification-preferences", tag: "user", summary: "Save notification preferences",
+		description: "The channel is one of log, email, webhook, none.",
+		params:      []param{userId}, body: model.Preference{},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.preference.Save },
+	},
+	{
+		method: http.MethodPost, path: "/users/{id}/budgets", tag: "budget", summary: "Create budget",
+		description: "An empty service_name covers all services, a category covers its services; the period is month or year.",
+		params:      []param{userId}, body: model.Budget{},
+		status: http.StatusCreated, response: model.Budget{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.budget.Create },
+	},
+	{
+		method: http.MethodGet, path: "/users/{id}/budgets", tag: "budget", summary: "List budgets",
+		params: []param{userId},
+		status: http.StatusOK, response: []model.Budget{},
+		handler: func(h *handlers) http.HandlerFunc { return h.budget.List },
+	},
+	{
+		method: http.MethodGet, path: "/users/{id}/budgets/status", tag: "budget", summary: "Budget status",
+		description: "Spending of the current period against every budget of the user, with the thresholds reached.",
+		params:      []param{userId},
+		status:      http.StatusOK, response: []model.BudgetStatus{},
+		handler: func(h *handlers) http.HandlerFunc { return h.budget.Status },
+	},
+	{
+		method: http.MethodGet, path: "/users/{id}/budgets/{budget_id}", tag: "budget", summary: "Read budget",
+		params: []param{userId, budgetId},
+		status: http.StatusOK, response: model.Budget{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.budget.Read },
+	},
+	{
+		method: http.MethodPut, path: "/users/{id}/budgets/{budget_id}", tag: "budget", summary: "Update budget",
+		params: []param{userId, budgetId}, body: model.Budget{},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.budget.Update },
+	},
+	{
+		method: http.MethodDelete, path: "/users/{id}/budgets/{budget_id}", tag: "budget", summary: "Delete budget",
+		params: []param{userId, budgetId},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.budget.Delete },
+	},
+	{
+		method: http.MethodGet, path: "/reports/forecast", tag: "report", summary: "Spending forecast",
+		description: "Monthly spend projected from subscriptions that have not ended at their scheduled prices less discounts, starting with the next month.",
+		params: []param{
+			{name: "user_id", in: "query", kind: "string", required: true, description: "user ID"},
+			{name: "months", in: "query", kind: "integer", description: "number of months, 12 by default"},
+		},
+		status: http.StatusOK, response: model.Forecast{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.report.Forecast },
+	},
+	{
+		method: http.MethodGet, path: "/reports/overlaps", tag: "report", summary: "Overlapping subscriptions",
+		description: "Pairs of subscriptions of a user to the same service with intersecting periods.",
+		params: []param{
+			{name: "user_id", in: "query", kind: "string", description: "user ID, all users when empty"},
+		},
+		status: http.StatusOK, response: []model.Overlap{},
+		handler: func(h *handlers) http.HandlerFunc { return h.report.Overlaps },
+	},
+	{
+		method: http.MethodGet, path: "/analytics/mrr", tag: "analytics", summary: "Monthly recurring revenue",
+		description: "MRR of every month in the range with new, expansion, contraction and churned MRR against the previous month.",
+		params:      monthRange,
+		status:      http.StatusOK, response: []model.MRRMonth{}, statuses: []int{http.StatusNotModified, http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.report.MRR },
+	},
+	{
+		method: http.MethodGet, path: "/analytics/subscribers", tag: "analytics", summary: "Active subscribers per service",
+		params: monthRange,
+		status: http.StatusOK, response: []model.ServiceSubscribers{}, statuses: []int{http.StatusNotModified, http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.report.Subscribers },
+	},
+	{
+		method: http.MethodGet, path: "/analytics/retention", tag: "analytics", summary: "Cohort retention",
+		description: "Users grouped by the month of their first subscription, with the number still subscribed each month after.",
+		params:      monthRange,
+		status:      http.StatusOK, response: []model.Cohort{}, statuses: []int{http.StatusNotModified, http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.report.Retention },
+	},
+	{
+		method: http.MethodGet, path: "/openapi.json", tag: "docs", summary: "OpenAPI document",
+		description: "The OpenAPI 3.1 document of the API version.",
+		status:      http.StatusOK, response: json.RawMessage{},
+		handler: func(h *handlers) http.HandlerFunc { return h.version.OpenAPI },
+	},
+	{
+		version: "v2", method: http.MethodPost, path: "/subscriptions", tag: "subscription", summary: "Create subscription",
+		body: model.SubscriptionV2{}, status: http.StatusCreated, statuses: []int{http.StatusBadRequest, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Create },
+	},
+	{
+		version: "v2", method: http.MethodGet, path: "/subscriptions", tag: "subscription", summary: "List subscriptions",
+		params: []param{
+			{name: "user_id", in: "query", kind: "string", description: "user ID"},
+			includeDeleted,
+			asOf,
+			{name: "trial_ends_within", in: "query", kind: "string", format: formatDuration, description: "only trials ending within the duration, e.g. 168h"},
+			{name: "limit", in: "query", kind: "integer", description: "page size"},
+			{name: "after_id", in: "query", kind: "integer", format: "int64", description: "only subscriptions with a greater ID, the last ID of the previous page"},
+		},
+		status: http.StatusOK, response: []model.SubscriptionV2{}, statuses: []int{http.StatusBadRequest},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.List },
+	},
+	{
+		version: "v2", method: http.MethodGet, path: "/subscriptions/{id}", tag: "subscription", summary: "Read subscription",
+		description: "The current state of a live subscription lists the price billed from each month it changes.",
+		params:      []param{subscriptionId, includeDeleted, asOf},
+		status:      http.StatusOK, response: model.SubscriptionDetailV2{}, statuses: []int{http.StatusBadRequest, http.StatusNotFound},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Read },
+	},
+	{
+		version: "v2", method: http.MethodPut, path: "/subscriptions/{id}", tag: "subscription", summary: "Update subscription",
+		params: []param{subscriptionId}, body: model.SubscriptionV2{},
+		status: http.StatusNoContent, statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		handler: func(h *handlers) http.HandlerFunc { return h.subscription.Update },
+	},
+}
+
+// operationsOf are the operations of an API version, with the month parameters in the format of the version.
+func operationsOf(version, monthFormat string) []operation {
+	var ops []operation
+	for _, op := range operations {
+		if op.version == version {
+			ops = append(ops, op)
+		}
+	}
+
+	for _, op := range operations {
+		replaced := slices.ContainsFunc(ops, func(versioned operation) bool {
+			return versioned.method == op.method && versioned.path == op.path
+		})
+		if op.version == "" && !replaced {
+			ops = append(ops, op)
+		}
+	}
+
+	for i := range ops {
+		params := slices.Clone(ops[i].params)
+		for j := range params {
+			if params[j].format == formatMonth {
+				params[j].format = monthFormat
+			}
+		}
+		ops[i].params = params
+	}
+
+	return ops
+}
+
+func (v *version) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	document, err := v.document()
+	if err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(document)
+}
+
+func (v *version) openAPI() map[string]any {
+	components := make(map[string]any)
+	paths := make(map[string]map[string]any)
+	for _, op := range v.operations {
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]any)
+		}
+		paths[op.path][strings.ToLower(op.method)] = op.document(components)
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Users online subscriptions",
+			"version": strings.TrimPrefix(v.name, "v") + ".0",
+		},
+		"servers": []any{map[string]any{"url": v.prefix()}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": components,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "error",
+					"content": map[string]any{
+						"text/plain": map[string]any{"schema": map[string]any{"type": "string"}},
+					},
+				},
+			},
+			"securitySchemes": map[string]any{
+				"UserId":   map[string]any{"type": "apiKey", "in": "header", "name": auth.UserIdHeader},
+				"UserRole": map[string]any{"type": "apiKey", "in": "header", "name": auth.UserRoleHeader},
+			},
+		},
+		"security": []any{map[string]any{"UserId": []string{}, "UserRole": []string{}}},
+	}
+}
+
+func (op *operation) document(components map[string]any) map[string]any {
+	document := map[string]any{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": op.method + " " + op.path,
+	}
+	if op.description != "" {
+		document["description"] = op.description
+	}
+
+	if len(op.params) > 0 {
+		params := make([]any, len(op.params))
+		for i, p := range op.params {
+			params[i] = p.document()
+		}
+		document["parameters"] = params
+	}
+
+	if op.body != nil {
+		document["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(op.body), components)},
+			},
+		}
+	}
+
+	success := map[string]any{"description": http.StatusText(op.status)}
+	if op.response != nil {
+		success["content"] = map[string]any{
+			"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(op.response), components)},
+		}
+	}
+	responses := map[string]any{strconv.Itoa(op.status): success}
+	for _, status := range append([]int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError}, op.statuses...) {
+		if status == http.StatusNotModified {
+			responses[strconv.Itoa(status)] = map[string]any{"description": http.StatusText(status)}
+			continue
+		}
+		responses[strconv.Itoa(status)] = map[string]any{"$ref": "#/components/responses/Error"}
+	}
+	document["responses"] = responses
+
+	return document
+}
+
+func (p *param) document() map[string]any {
+	schema := map[string]any{"type": p.kind}
+	switch p.format {
+	case "":
+	case formatMonth:
+		schema["pattern"] = monthPattern.String()
+	case formatISOMonth:
+		schema["pattern"] = isoMonthPattern.String()
+	default:
+		schema["format"] = p.format
+	}
+	if len(p.enum) > 0 {
+		schema["enum"] = p.enum
+	}
+
+	document := map[string]any{
+		"name":     p.name,
+		"in":       p.in,
+		"required": p.required,
+		"schema":   schema,
+	}
+	if p.description != "" {
+		document["description"] = p.description
+	}
+
+	return document
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaOf is the JSON Schema of the JSON encoding of t. Structs are added to components and referenced by name.
+func schemaOf(t reflect.Type, components map[string]any) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaOf(t.Elem(), components))
+	case reflect.Slice:
+		return nullable(map[string]any{"type": "array", "items": schemaOf(t.Elem(), components)})
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), components)}
+	case reflect.Struct:
+		if _, ok := components[t.Name()]; !ok {
+			// The placeholder ends the recursion of self-referencing types.
+			components[t.Name()] = map[string]any{}
+			properties := make(map[string]any)
+			for name, field := range jsonFields(t) {
+				properties[name] = schemaOf(field.Type, components)
+			}
+			components[t.Name()] = map[string]any{
+				"type":                 "object",
+				"properties":           properties,
+				"additionalProperties": false,
+			}
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+func nullable(schema map[string]any) map[string]any {
+	if kind, ok := schema["type"].(string); ok {
+		schema["type"] = []string{kind, "null"}
+		return schema
+	}
+
+	return map[string]any{"oneOf": []any{schema, map[string]any{"type": "null"}}}
+}
+
+// jsonFields are the fields of a struct by the names encoding/json gives them, with embedded structs flattened.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || (field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = field
+	}
+
+	return fields
+}
+
+// OpenAPIDocument is the OpenAPI 3.1 document of the routes of an API version.
+func OpenAPIDocument(version string) ([]byte, error) {
+	v, err := versionOf(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.document()
+}
+
+// Divergences lists the operations of the OpenAPI documents that the routes do not serve as described: a request to
+// the operation that is built from its parameters either does not pass the validation, or reaches no handler or one
+// that does not accept its method. The routes are registered from the operations, so none is served undescribed.
+func Divergences() []string {
+	h := New(nil, nil, nil, nil, nil, nil, nil, http.NotFoundHandler(), nil, nil)
+
+	var divergences []string
+	for _, v := range versions {
+		routes := h.routes(v)
+		for i := range v.operations {
+			op := &v.operations[i]
+			r := op.probe()
+			if err := op.validate(r, op.examplePathValues()); err != nil {
+				divergences = append(divergences, fmt.Sprintf("%s %s%s: %v", op.method, v.prefix(), op.path, err))
+				continue
+			}
+
+			if status := serve(routes, r); status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+				divergences = append(divergences, fmt.Sprintf("%s %s%s: %d %s", op.method, v.prefix(), op.path, status, http.StatusText(status)))
+			}
+		}
+	}
+
+	return divergences
+}
+
+// serve returns the status of the response, or 0 when the handler was reached: the probe has no services behind the
+// handlers, so calling one panics.
+func serve(routes http.Handler, r *http.Request) (status int) {
+	defer func() {
+		if recover() != nil {
+			status = 0
+		}
+	}()
+
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, r)
+	return w.Code
+}
+
+func (op *operation) probe() *http.Request {
+	path := op.path
+	for name, value := range op.examplePathValues() {
+		path = strings.Replace(path, "{"+name+"}", value, 1)
+	}
+
+	query := url.Values{}
+	var body io.Reader
+	if op.body != nil {
+		body = strings.NewReader("{}")
+	}
+	r := httptest.NewRequest(op.method, path, body)
+	for _, p := range op.params {
+		if !p.required {
+			continue
+		}
+		switch p.in {
+		case "query":
+			query.Set(p.name, p.example())
+		case "header":
+			r.Header.Set(p.name, p.example())
+		}
+	}
+	r.URL.RawQuery = query.Encode()
+
+	return r
+}
+
+func (op *operation) examplePathValues() map[string]string {
+	values := make(map[string]string)
+	for _, p := range op.params {
+		if p.in == "path" {
+			values[p.name] = p.example()
+		}
+	}
+
+	return values
+}
+
+func (p *param) example() string {
+	switch {
+	case len(p.enum) > 0:
+		return p.enum[0]
+	case p.format == formatMonth:
+		return "01-2025"
+	case p.format == formatISOMonth:
+		return "2025-01"
+	case p.format == "date-time":
+		return "2025-01-01T00:00:00Z"
+	case p.format == formatDuration:
+		return "24h"
+	case p.kind == "integer":
+		return "1"
+	case p.kind == "boolean":
+		return "true"
+	default:
+		return "user"
+	}
+}
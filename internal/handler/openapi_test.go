@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoutesServeOpenAPIOperations(t *testing.T) {
+	for _, divergence := range Divergences() {
+		t.Error(divergence)
+	}
+}
+
+func TestOpenAPIDocumentsDescribeThemselves(t *testing.T) {
+	for _, v := range versions {
+		raw, err := OpenAPIDocument(v.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var document struct {
+			Paths map[string]map[string]any `json:"paths"`
+		}
+		if err := json.Unmarshal(raw, &document); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := document.Paths["/openapi.json"]["get"]; !ok {
+			t.Errorf("the %s document does not describe GET /openapi.json", v.name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Preference interface {
+	Read(ctx context.Context, userId string) (*model.Preference, error)
+	Save(ctx context.Context, userId string, preference *model.Preference) error
+}
+
+type PreferenceHandler struct {
+	preferenceService Preference
+}
+
+func NewPreferenceHandler(preferenceService Preference) *PreferenceHandler {
+	return &PreferenceHandler{
+		preferenceService: preferenceService,
+	}
+}
+
+func (h *PreferenceHandler) Read(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+
+	preference, err := h.preferenceService.Read(r.Context(), userId)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(preference); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *PreferenceHandler) Save(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("id")
+
+	preference := new(model.Preference)
+	if err := json.NewDecoder(r.Body).Decode(preference); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.preferenceService.Save(r.Context(), userId, preference); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+func (h *SubscriptionHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	price := new(model.ScheduledPrice)
+	if err := json.NewDecoder(r.Body).Decode(price); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if price.StartDate, err = h.representation.month(price.StartDate); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.SchedulePrice(r.Context(), id, price); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	price.StartDate = h.representation.encodeMonth(price.StartDate)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(price); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *SubscriptionHandler) RemovePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	priceId, err := strconv.ParseInt(r.PathValue("price_id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.RemovePrice(r.Context(), id, priceId); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) ScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.subscriptionService.ScheduledPrices(r.Context(), id)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, price := range list {
+		price.StartDate = h.representation.encodeMonth(price.StartDate)
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
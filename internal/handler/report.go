@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+const defaultForecastMonths = 12
+
+// analyticsMaxAge is how long clients and proxies may reuse analytics responses.
+const analyticsMaxAge = 5 * time.Minute
+
+type Report interface {
+	Forecast(ctx context.Context, userId string, months int) (*model.Forecast, error)
+	MRR(ctx context.Context, from, to time.Time) ([]*model.MRRMonth, error)
+	Subscribers(ctx context.Context, from, to time.Time) ([]*model.ServiceSubscribers, error)
+	Retention(ctx context.Context, from, to time.Time) ([]*model.Cohort, error)
+	Overlaps(ctx context.Context, userId string) ([]*model.Overlap, error)
+}
+
+type ReportHandler struct {
+	reportService  Report
+	representation representation
+}
+
+func NewReportHandler(reportService Report) *ReportHandler {
+	return &ReportHandler{
+		reportService:  reportService,
+		representation: v1{},
+	}
+}
+
+// in is the handler with the representation of months of an API version.
+func (h *ReportHandler) in(v *version) *ReportHandler {
+	return &ReportHandler{
+		reportService:  h.reportService,
+		representation: v.representation,
+	}
+}
+
+func (h *ReportHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userId := query.Get("user_id")
+	if userId == "" {
+		logger.HttpError(w, fmt.Errorf("%w: query parameter user_id is required", err_msg.InvalidReport), http.StatusBadRequest)
+		return
+	}
+
+	months := defaultForecastMonths
+	if value := query.Get("months"); value != "" {
+		var err error
+		if months, err = strconv.Atoi(value); err != nil {
+			logger.HttpError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	forecast, err := h.reportService.Forecast(r.Context(), userId, months)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, month := range forecast.Months {
+		month.Month = h.representation.encodeMonth(month.Month)
+	}
+
+	if err := json.NewEncoder(w).Encode(forecast); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *ReportHandler) Overlaps(w http.ResponseWriter, r *http.Request) {
+	overlaps, err := h.reportService.Overlaps(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, overlap := range overlaps {
+		overlap.StartDate = h.representation.encodeMonth(overlap.StartDate)
+		overlap.EndDate = h.representation.encodeMonth(overlap.EndDate)
+	}
+
+	if err := json.NewEncoder(w).Encode(overlaps); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *ReportHandler) MRR(w http.ResponseWriter, r *http.Request) {
+	from, to, err := h.parseRange(r)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.reportService.MRR(r.Context(), from, to)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, month := range list {
+		month.Month = h.representation.encodeMonth(month.Month)
+	}
+
+	writeCacheable(w, r, list)
+}
+
+func (h *ReportHandler) Subscribers(w http.ResponseWriter, r *http.Request) {
+	from, to, err := h.parseRange(r)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.reportService.Subscribers(r.Context(), from, to)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, subscribers := range list {
+		subscribers.Month = h.representation.encodeMonth(subscribers.Month)
+	}
+
+	writeCacheable(w, r, list)
+}
+
+func (h *ReportHandler) Retention(w http.ResponseWriter, r *http.Request) {
+	from, to, err := h.parseRange(r)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.reportService.Retention(r.Context(), from, to)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	for _, cohort := range list {
+		cohort.Month = h.representation.encodeMonth(cohort.Month)
+	}
+
+	writeCacheable(w, r, list)
+}
+
+// parseRange reads the from and to months of an analytics request in the representation of the version.
+func (h *ReportHandler) parseRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	if query.Get("from") == "" || query.Get("to") == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: query parameters from and to are required", err_msg.InvalidReport)
+	}
+
+	from, err := h.parseMonth(query.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to, err := h.parseMonth(query.Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return from, to, nil
+}
+
+func (h *ReportHandler) parseMonth(value string) (time.Time, error) {
+	month, err := h.representation.month(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(monthLayout, month)
+}
+
+// writeCacheable writes the response with an ETag and lets clients reuse it for analyticsMaxAge,
+// answering 304 when the client already has the same result.
+func writeCacheable(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(analyticsMaxAge.Seconds())))
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		logger.Error(err)
+	}
+}
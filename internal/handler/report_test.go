@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// fakeReports records the range of the MRR request and reports a single month.
+type fakeReports struct {
+	Report
+	from, to time.Time
+}
+
+func (f *fakeReports) MRR(_ context.Context, from, to time.Time) ([]*model.MRRMonth, error) {
+	f.from, f.to = from, to
+	return []*model.MRRMonth{{Month: "01-2025", Subscribers: 1, MRR: 100}}, nil
+}
+
+func TestForecastNamesMissingUserId(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewReportHandler(nil).Forecast(w, httptest.NewRequest(http.MethodGet, "/reports/forecast?months=3", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(w.Body.String(), "user_id") {
+		t.Errorf("message %q does not name user_id", w.Body.String())
+	}
+}
+
+func TestV2AnalyticsUseISOMonths(t *testing.T) {
+	reports := &fakeReports{}
+	h := New(nil, nil, nil, nil, nil, reports, nil, http.NotFoundHandler(), nil, nil)
+	v, err := versionOf("v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := h.versionRoutes(v, v.name)
+
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/mrr?from=2025-01&to=2025-03", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if want := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC); !reports.from.Equal(want) {
+		t.Errorf("from %s, want %s", reports.from, want)
+	}
+	if want := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC); !reports.to.Equal(want) {
+		t.Errorf("to %s, want %s", reports.to, want)
+	}
+	if !strings.Contains(w.Body.String(), `"month":"2025-01"`) {
+		t.Errorf("body %s does not have the month as YYYY-MM", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/mrr?from=01-2025&to=03-2025", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d for MM-YYYY months, want %d", w.Code, http.StatusBadRequest)
+	}
+}
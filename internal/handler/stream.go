@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+type Stream interface {
+	Stream(ctx context.Context, filter *events.Filter, lastEventId int64) (<-chan *events.Envelope, error)
+}
+
+type StreamHandler struct {
+	streamService Stream
+}
+
+func NewStreamHandler(streamService Stream) *StreamHandler {
+	return &StreamHandler{
+		streamService: streamService,
+	}
+}
+
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var (
+		lastEventId int64
+		err         error
+	)
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		if lastEventId, err = strconv.ParseInt(value, 10, 64); err != nil {
+			logger.HttpError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	stream, err := h.streamService.Stream(r.Context(), &events.Filter{
+		UserId:      query.Get("user_id"),
+		ServiceName: query.Get("service_name"),
+	}, lastEventId)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		logger.Error(err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case envelope, ok := <-stream:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(envelope)
+			if err != nil {
+				logger.Error(err)
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", envelope.ID, envelope.Type, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -3,10 +3,12 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/oatsmoke/20250905/internal/lib/err_msg"
 	"github.com/oatsmoke/20250905/internal/lib/logger"
 	"github.com/oatsmoke/20250905/internal/model"
@@ -14,41 +16,50 @@ import (
 
 type Subscription interface {
 	Create(ctx context.Context, data *model.ExternalData) error
-	Read(ctx context.Context, subscriptionId int64) (*model.ExternalData, error)
+	Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.SubscriptionDetail, error)
 	Update(ctx context.Context, subscriptionId int64, data *model.ExternalData) error
 	Delete(ctx context.Context, subscriptionId int64) error
-	List(ctx context.Context) ([]*model.ExternalData, error)
-	Total(ctx context.Context, data *model.ExternalData) (int64, error)
+	Restore(ctx context.Context, subscriptionId int64) error
+	List(ctx context.Context, filter *model.Filter) ([]*model.ExternalData, error)
+	Total(ctx context.Context, data *model.ExternalData, filter *model.Filter) (int64, error)
+	History(ctx context.Context, subscriptionId int64, filter *model.AuditFilter) ([]*model.AuditRecord, error)
+	Pause(ctx context.Context, subscriptionId int64) error
+	Resume(ctx context.Context, subscriptionId int64) error
+	Cancel(ctx context.Context, subscriptionId int64, effective string) error
+	AddDiscount(ctx context.Context, subscriptionId int64, discount *model.Discount) error
+	RemoveDiscount(ctx context.Context, subscriptionId, discountId int64) error
+	Discounts(ctx context.Context, subscriptionId int64) ([]*model.Discount, error)
+	AddMember(ctx context.Context, subscriptionId int64, member *model.Member) error
+	RemoveMember(ctx context.Context, subscriptionId, memberId int64, from string) error
+	Members(ctx context.Context, subscriptionId int64) ([]*model.Member, error)
+	SchedulePrice(ctx context.Context, subscriptionId int64, price *model.ScheduledPrice) error
+	RemovePrice(ctx context.Context, subscriptionId, priceId int64) error
+	ScheduledPrices(ctx context.Context, subscriptionId int64) ([]*model.ScheduledPrice, error)
 }
 
 type SubscriptionHandler struct {
 	subscriptionService Subscription
+	representation      representation
 }
 
 func NewSubscriptionHandler(subscriptionService Subscription) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		subscriptionService: subscriptionService,
+		representation:      v1{},
 	}
 }
 
-// Create
-// @Summary Create subscription
-// @Tags subscription
-// @Produce json
-// @Param request body model.ExternalData true "external data"
-// @Success 201
-// @Failure 400 {object} string "bad request"
-// @Failure 405 {object} string "method not allowed"
-// @Failure 500 {object} string "internal server error"
-// @Router /subscriptions [post]
-func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		logger.HttpError(w, err_msg.MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
+// in is the handler with the representation of subscriptions of an API version.
+func (h *SubscriptionHandler) in(v *version) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionService: h.subscriptionService,
+		representation:      v.representation,
 	}
+}
 
-	subscription := new(model.ExternalData)
-	if err := json.NewDecoder(r.Body).Decode(subscription); err != nil {
+func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.representation.decode(r.Body)
+	if err != nil {
 		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
@@ -59,72 +70,47 @@ func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.subscriptionService.Create(r.Context(), subscription); err != nil {
-		logger.HttpError(w, err, http.StatusInternalServerError)
+		logger.HttpError(w, err, errorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
-// Read
-// @Summary Read subscription
-// @Tags subscription
-// @Produce json
-// @Param id path int true "id subscription"
-// @Success 200 {object} model.ExternalData
-// @Failure 400 {object} string "bad request"
-// @Failure 405 {object} string "method not allowed"
-// @Failure 500 {object} string "internal server error"
-// @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) Read(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		logger.HttpError(w, err_msg.MethodNotAllowed, http.StatusMethodNotAllowed)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/subscriptions/"), 10, 64)
+	filter, err := parseFilter(r)
 	if err != nil {
 		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	subscription, err := h.subscriptionService.Read(r.Context(), id)
+	subscription, err := h.subscriptionService.Read(r.Context(), id, filter)
 	if err != nil {
-		logger.HttpError(w, err, http.StatusInternalServerError)
+		logger.HttpError(w, err, errorStatus(err))
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(subscription); err != nil {
+	if err := json.NewEncoder(w).Encode(h.representation.encodeDetail(subscription)); err != nil {
 		logger.HttpError(w, err, http.StatusInternalServerError)
 		return
 	}
 }
 
-// Update
-// @Summary Update subscription
-// @Tags subscription
-// @Produce json
-// @Param id path int true "id subscription"
-// @Param request body model.ExternalData true "external data"
-// @Success 204
-// @Failure 400 {object} string "bad request"
-// @Failure 405 {object} string "method not allowed"
-// @Failure 500 {object} string "internal server error"
-// @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		logger.HttpError(w, err_msg.MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
-	subscriptionId, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/subscriptions/"), 10, 64)
+	subscriptionId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	subscription := new(model.ExternalData)
-	if err := json.NewDecoder(r.Body).Decode(subscription); err != nil {
+	subscription, err := h.representation.decode(r.Body)
+	if err != nil {
 		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
@@ -135,88 +121,77 @@ func (h *SubscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.subscriptionService.Update(r.Context(), subscriptionId, subscription); err != nil {
-		logger.HttpError(w, err, http.StatusInternalServerError)
+		logger.HttpError(w, err, errorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Delete
-// @Summary Delete subscription
-// @Tags subscription
-// @Produce json
-// @Param id path int true "id subscription"
-// @Success 204
-// @Failure 400 {object} string "bad request"
-// @Failure 405 {object} string "method not allowed"
-// @Failure 500 {object} string "internal server error"
-// @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		logger.HttpError(w, err_msg.MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
-	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/subscriptions/"), 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := h.subscriptionService.Delete(r.Context(), id); err != nil {
-		logger.HttpError(w, err, http.StatusInternalServerError)
+		logger.HttpError(w, err, errorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// List
-// @Summary List subscriptions
-// @Tags subscription
-// @Produce json
-// @Success 200 {array} model.ExternalData
-// @Failure 405 {object} string "method not allowed"
-// @Failure 500 {object} string "internal server error"
-// @Router /subscriptions [get]
 func (h *SubscriptionHandler) List(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		logger.HttpError(w, err_msg.MethodNotAllowed, http.StatusMethodNotAllowed)
+	filter, err := parseFilter(r)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	list, err := h.subscriptionService.List(r.Context())
+	if value := r.URL.Query().Get("trial_ends_within"); value != "" {
+		within, err := time.ParseDuration(value)
+		if err != nil {
+			logger.HttpError(w, err, http.StatusBadRequest)
+			return
+		}
+		trialEndsBy := time.Now().Add(within)
+		filter.TrialEndsBy = &trialEndsBy
+	}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 {
+			logger.HttpError(w, err_msg.InvalidPage, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if value := r.URL.Query().Get("after_id"); value != "" {
+		if filter.AfterId, err = strconv.ParseInt(value, 10, 64); err != nil {
+			logger.HttpError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	list, err := h.subscriptionService.List(r.Context(), filter)
 	if err != nil {
-		logger.HttpError(w, err, http.StatusInternalServerError)
+		logger.HttpError(w, err, errorStatus(err))
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(list); err != nil {
+	subscriptions := make([]any, 0, len(list))
+	for _, data := range list {
+		subscriptions = append(subscriptions, h.representation.encode(data))
+	}
+
+	if err := json.NewEncoder(w).Encode(subscriptions); err != nil {
 		logger.HttpError(w, err, http.StatusInternalServerError)
 		return
 	}
 }
 
-// Total
-// @Summary Total subscriptions
-// @Tags subscription
-// @Produce json
-// @Param user_id query string true "user ID"
-// @Param service_name query string true "service name"
-// @Param start_date query string true "start date"
-// @Param end_date query string true "end date"
-// @Success 200 {object} int
-// @Failure 400 {object} string "bad request"
-// @Failure 405 {object} string "method not allowed"
-// @Failure 500 {object} string "internal server error"
-// @Router /subscriptions/total [get]
 func (h *SubscriptionHandler) Total(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		logger.HttpError(w, err_msg.MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
 	query := r.URL.Query()
 	userId := query.Get("user_id")
 	serviceName := query.Get("service_name")
@@ -228,14 +203,31 @@ func (h *SubscriptionHandler) Total(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var err error
+	if startDate, err = h.representation.month(startDate); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if endDate, err = h.representation.month(endDate); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
 	total, err := h.subscriptionService.Total(r.Context(), &model.ExternalData{
 		UserId:      userId,
 		ServiceName: serviceName,
 		StartDate:   startDate,
 		EndDate:     endDate,
-	})
+	}, filter)
 	if err != nil {
-		logger.HttpError(w, err, http.StatusInternalServerError)
+		logger.HttpError(w, err, errorStatus(err))
 		return
 	}
 
@@ -244,3 +236,168 @@ func (h *SubscriptionHandler) Total(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func (h *SubscriptionHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.Restore(r.Context(), id); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.Pause(r.Context(), id); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscriptionService.Resume(r.Context(), id); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	effective := r.URL.Query().Get("effective")
+	if effective == "" {
+		effective = model.CancelPeriodEnd
+	}
+
+	if err := h.subscriptionService.Cancel(r.Context(), id, effective); err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &model.AuditFilter{
+		Actor: query.Get("actor"),
+	}
+
+	if filter.From, err = parseTime(query.Get("from")); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if filter.To, err = parseTime(query.Get("to")); err != nil {
+		logger.HttpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.subscriptionService.History(r.Context(), id, filter)
+	if err != nil {
+		logger.HttpError(w, err, errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		logger.HttpError(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseFilter(r *http.Request) (*model.Filter, error) {
+	var err error
+	query := r.URL.Query()
+	filter := &model.Filter{
+		UserId: query.Get("user_id"),
+	}
+
+	if value := query.Get("include_deleted"); value != "" {
+		if filter.IncludeDeleted, err = strconv.ParseBool(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.AsOf, err = parseTime(query.Get("as_of")); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, err_msg.Unauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, err_msg.Forbidden):
+		return http.StatusForbidden
+	case errors.Is(err, err_msg.RequestBodyIsEmpty),
+		errors.Is(err, err_msg.LaterDate),
+		errors.Is(err, err_msg.InvalidWebhook),
+		errors.Is(err, err_msg.InvalidPreference),
+		errors.Is(err, err_msg.InvalidBudget),
+		errors.Is(err, err_msg.InvalidReport),
+		errors.Is(err, err_msg.InvalidCancel),
+		errors.Is(err, err_msg.InvalidTrial),
+		errors.Is(err, err_msg.InvalidDiscount),
+		errors.Is(err, err_msg.InvalidMember),
+		errors.Is(err, err_msg.InvalidPrice),
+		errors.Is(err, err_msg.InvalidPage),
+		errors.Is(err, err_msg.InvalidRequest),
+		errors.As(err, new(*time.ParseError)),
+		errors.As(err, new(*strconv.NumError)):
+		return http.StatusBadRequest
+	case errors.Is(err, err_msg.NoRowsAffected),
+		errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, err_msg.Overlap),
+		errors.Is(err, err_msg.InvalidTransition):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
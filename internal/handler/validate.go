@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/lib/middleware"
+)
+
+var (
+	monthPattern    = regexp.MustCompile(`^(0[1-9]|1[0-2])-[0-9]{4}$`)
+	isoMonthPattern = regexp.MustCompile(`^[0-9]{4}-(0[1-9]|1[0-2])$`)
+)
+
+// validate rejects requests to the operations of the OpenAPI document whose parameters or body do not match it.
+// Requests to paths the document does not describe are passed on as they are.
+func validate(operations []operation) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, values := match(operations, r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := op.validate(r, values); err != nil {
+				logger.HttpError(w, fmt.Errorf("%w: %w", err_msg.InvalidRequest, err), http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// match finds the operation of a request with the values of its path parameters. Of the paths that match, the one
+// with the most literal segments wins, so /subscriptions/total is not read as /subscriptions/{id}.
+func match(operations []operation, method, path string) (*operation, map[string]string) {
+	segments := strings.Split(path, "/")
+	var matched string
+	var values map[string]string
+	literals := -1
+	for _, op := range operations {
+		if v, n, ok := matchPath(strings.Split(op.path, "/"), segments); ok && n > literals {
+			matched, values, literals = op.path, v, n
+		}
+	}
+
+	for i := range operations {
+		if operations[i].path == matched && operations[i].method == method {
+			return &operations[i], values
+		}
+	}
+
+	return nil, nil
+}
+
+func matchPath(template, segments []string) (map[string]string, int, bool) {
+	if len(template) != len(segments) {
+		return nil, 0, false
+	}
+
+	values := make(map[string]string)
+	literals := 0
+	for i, segment := range template {
+		if strings.HasPrefix(segment, "{") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			values[strings.Trim(segment, "{}")] = segments[i]
+			continue
+		}
+
+		if segment != segments[i] {
+			return nil, 0, false
+		}
+		literals++
+	}
+
+	return values, literals, true
+}
+
+func (op *operation) validate(r *http.Request, pathValues map[string]string) error {
+	query := r.URL.Query()
+	for _, p := range op.params {
+		var value string
+		switch p.in {
+		case "path":
+			value = pathValues[p.name]
+		case "query":
+			value = query.Get(p.name)
+		case "header":
+			value = r.Header.Get(p.name)
+		}
+
+		if value == "" {
+			if p.required {
+				return fmt.Errorf("%s parameter %s is required", p.in, p.name)
+			}
+			continue
+		}
+
+		if err := p.validate(value); err != nil {
+			return fmt.Errorf("%s parameter %s: %w", p.in, p.name, err)
+		}
+	}
+
+	if op.body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// An empty body is left to the handler, which tells why it needs one.
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	if err := validateValue(value, reflect.TypeOf(op.body), "body"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *param) validate(value string) error {
+	var err error
+	switch p.kind {
+	case "integer":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return errors.New("must be " + p.kind)
+	}
+
+	switch p.format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case formatDuration:
+		_, err = time.ParseDuration(value)
+	case formatMonth:
+		if !monthPattern.MatchString(value) {
+			err = errors.New("not a month")
+		}
+	case formatISOMonth:
+		if !isoMonthPattern.MatchString(value) {
+			err = errors.New("not a month")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("must be %s", p.format)
+	}
+
+	if len(p.enum) > 0 && !slices.Contains(p.enum, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(p.enum, ", "))
+	}
+
+	return nil
+}
+
+// validateValue checks a decoded JSON value against the schema of t, following the rules of schemaOf.
+func validateValue(value any, t reflect.Type, path string) error {
+	switch t {
+	case timeType:
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339, s); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be date-time", path)
+	case rawMessageType:
+		return nil
+	}
+
+	if value == nil {
+		if t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+			return nil
+		}
+		return fmt.Errorf("%s must not be null", path)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return validateValue(value, t.Elem(), path)
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be array", path)
+		}
+		for i, item := range items {
+			if err := validateValue(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be object", path)
+		}
+		for key, item := range object {
+			if err := validateValue(item, t.Elem(), path+"."+key); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be object", path)
+		}
+		fields := jsonFields(t)
+		for key, item := range object {
+			field, ok := fields[key]
+			if !ok {
+				return fmt.Errorf("%s.%s is not a known property", path, key)
+			}
+			if err := validateValue(item, field.Type, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be boolean", path)
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be integer", path)
+		}
+		if _, err := strconv.ParseInt(number.String(), 10, t.Bits()); err != nil {
+			return fmt.Errorf("%s must be integer", path)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s must be number", path)
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s must be string", path)
+		}
+	}
+
+	return nil
+}
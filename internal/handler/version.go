@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/middleware"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// version is a group of the API routes under the prefix of its name. Versions share the handlers and the services
+// behind them and differ in the representation of subscriptions and months. The paths without a prefix are aliases of
+// v1.
+type version struct {
+	name           string
+	representation representation
+	// successor is the version linked from the responses once this one is deprecated.
+	successor  string
+	operations []operation
+	document   func() ([]byte, error)
+}
+
+var versions = []*version{
+	newVersion("v1", v1{}, formatMonth, "v2"),
+	newVersion("v2", v2{}, formatISOMonth, ""),
+}
+
+func newVersion(name string, representation representation, monthFormat, successor string) *version {
+	v := &version{
+		name:           name,
+		representation: representation,
+		successor:      successor,
+		operations:     operationsOf(name, monthFormat),
+	}
+	// The document is built once, on the first request for it.
+	v.document = sync.OnceValues(func() ([]byte, error) {
+		return json.Marshal(v.openAPI())
+	})
+
+	return v
+}
+
+func versionOf(name string) (*version, error) {
+	for _, v := range versions {
+		if v.name == name {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown API version %q", name)
+}
+
+func (v *version) prefix() string {
+	return "/" + v.name
+}
+
+// Lifecycle announces the retirement of an API version in the Deprecation and Sunset headers of its responses. Zero
+// times are not announced.
+type Lifecycle struct {
+	Deprecation time.Time
+	Sunset      time.Time
+}
+
+// requests counts the requests to each version of the API, published at /debug/vars, to tell when a version is no
+// longer used. Requests to the paths without a version are counted as unversioned.
+var requests = expvar.NewMap("api_requests")
+
+const unversioned = "unversioned"
+
+// announce counts the request under metric and sets the lifecycle headers of the version. It expects the path
+// without the version prefix.
+func (v *version) announce(metric string, lifecycle Lifecycle) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(metric, 1)
+
+			if !lifecycle.Deprecation.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", lifecycle.Deprecation.Unix()))
+				if v.successor != "" {
+					w.Header().Set("Link", fmt.Sprintf(`</%s%s>; rel="successor-version"`, v.successor, r.URL.Path))
+				}
+			}
+
+			if !lifecycle.Sunset.IsZero() {
+				w.Header().Set("Sunset", lifecycle.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// representation converts subscriptions and months between the JSON of an API version and the service layer.
+type representation interface {
+	decode(body io.Reader) (*model.ExternalData, error)
+	encode(data *model.ExternalData) any
+	encodeDetail(detail *model.SubscriptionDetail) any
+	// month converts a month of a query parameter or a request body to the MM-YYYY of the service layer.
+	month(value string) (string, error)
+	// encodeMonth converts a month of the service layer to the representation of the version.
+	encodeMonth(value string) string
+}
+
+// v1 is the representation of the service layer: months are MM-YYYY.
+type v1 struct{}
+
+func (v1) decode(body io.Reader) (*model.ExternalData, error) {
+	data := new(model.ExternalData)
+	if err := json.NewDecoder(body).Decode(data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (v1) encode(data *model.ExternalData) any {
+	return data
+}
+
+func (v1) encodeDetail(detail *model.SubscriptionDetail) any {
+	return detail
+}
+
+func (v1) month(value string) (string, error) {
+	return value, nil
+}
+
+func (v1) encodeMonth(value string) string {
+	return value
+}
+
+// v2 represents months as ISO 8601 (YYYY-MM) and the service as an object.
+type v2 struct{}
+
+const (
+	monthLayout    = "01-2006"
+	isoMonthLayout = "2006-01"
+)
+
+func (v v2) decode(body io.Reader) (*model.ExternalData, error) {
+	subscription := new(model.SubscriptionV2)
+	if err := json.NewDecoder(body).Decode(subscription); err != nil {
+		return nil, err
+	}
+
+	data := &model.ExternalData{
+		ID:          subscription.ID,
+		ServiceName: subscription.Service.Name,
+		Price:       subscription.Price,
+		UserId:      subscription.UserId,
+		DeletedAt:   subscription.DeletedAt,
+		Status:      subscription.Status,
+	}
+
+	var err error
+	if data.StartDate, err = v.month(subscription.StartDate); err != nil {
+		return nil, err
+	}
+	if data.EndDate, err = v.month(subscription.EndDate); err != nil {
+		return nil, err
+	}
+	if data.TrialEndDate, err = v.month(subscription.TrialEndDate); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (v2) encode(data *model.ExternalData) any {
+	return toV2(data)
+}
+
+func (v2) encodeDetail(detail *model.SubscriptionDetail) any {
+	prices := make([]*model.PriceChange, len(detail.EffectivePrices))
+	for i, price := range detail.EffectivePrices {
+		prices[i] = &model.PriceChange{Month: isoMonth(price.Month), Price: price.Price}
+	}
+
+	return &model.SubscriptionDetailV2{
+		SubscriptionV2:  *toV2(&detail.ExternalData),
+		EffectivePrices: prices,
+	}
+}
+
+func (v2) month(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	month, err := time.Parse(isoMonthLayout, value)
+	if err != nil {
+		return "", err
+	}
+
+	return month.Format(monthLayout), nil
+}
+
+func (v2) encodeMonth(value string) string {
+	return isoMonth(value)
+}
+
+func toV2(data *model.ExternalData) *model.SubscriptionV2 {
+	return &model.SubscriptionV2{
+		ID:           data.ID,
+		Service:      model.ServiceV2{Name: data.ServiceName},
+		Price:        data.Price,
+		UserId:       data.UserId,
+		StartDate:    isoMonth(data.StartDate),
+		EndDate:      isoMonth(data.EndDate),
+		DeletedAt:    data.DeletedAt,
+		Status:       data.Status,
+		TrialEndDate: isoMonth(data.TrialEndDate),
+	}
+}
+
+// isoMonth converts a month of the service layer to YYYY-MM. The service layer only returns valid months.
+func isoMonth(value string) string {
+	month, err := time.Parse(monthLayout, value)
+	if err != nil {
+		return value
+	}
+
+	return month.Format(isoMonthLayout)
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+const (
+	UserIdHeader   = "X-User-Id"
+	UserRoleHeader = "X-User-Role"
+)
+
+// MaxUserIdLength is the length of the user_id columns.
+const MaxUserIdLength = 50
+
+type Actor struct {
+	ID   string
+	Role string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func FromContext(ctx context.Context) (*Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(*Actor)
+	return actor, ok && actor != nil
+}
+
+// Middleware trusts the identity headers set by the authenticating gateway in front of the service. A user id that
+// does not fit the user_id columns is answered with 400.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(UserIdHeader)
+		role := r.Header.Get(UserRoleHeader)
+		if err := ValidateUserId(id); err != nil {
+			logger.HttpError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if id != "" && role != "" {
+			r = r.WithContext(WithActor(r.Context(), &Actor{ID: id, Role: role}))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidateUserId rejects a user id longer than the user_id columns.
+func ValidateUserId(id string) error {
+	if utf8.RuneCountInString(id) > MaxUserIdLength {
+		return err_msg.InvalidUserId
+	}
+
+	return nil
+}
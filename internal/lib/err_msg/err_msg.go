@@ -1,10 +1,41 @@
 package err_msg
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	RequestBodyIsEmpty = errors.New("request body is empty")
 	NoRowsAffected     = errors.New("no rows affected")
-	MethodNotAllowed   = errors.New("method not allowed")
 	LaterDate          = errors.New("StartDate is later than EndDate")
+	Unauthorized       = errors.New("unauthorized")
+	Forbidden          = errors.New("forbidden")
+	InvalidWebhook     = errors.New("invalid webhook")
+	InvalidPreference  = errors.New("invalid notification preference")
+	InvalidBudget      = errors.New("invalid budget")
+	InvalidReport      = errors.New("invalid report parameters")
+	Overlap            = errors.New("subscription overlaps an existing subscription to the service")
+	InvalidTransition  = errors.New("subscription status does not allow the action")
+	InvalidCancel      = errors.New("invalid cancellation")
+	InvalidTrial       = errors.New("trial must end between StartDate and EndDate")
+	InvalidDiscount    = errors.New("invalid discount")
+	InvalidMember      = errors.New("invalid subscription member")
+	InvalidPrice       = errors.New("invalid scheduled price")
+	InvalidPage        = errors.New("limit must be a positive number")
+	InvalidRequest     = errors.New("request does not match the API specification")
+	InvalidUserId      = errors.New("user id is longer than 50 characters")
 )
+
+// OverlapError is an Overlap that names the subscription overlapped.
+type OverlapError struct {
+	SubscriptionId int64
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("%v: id %d", Overlap, e.SubscriptionId)
+}
+
+func (e *OverlapError) Unwrap() error {
+	return Overlap
+}
@@ -0,0 +1,66 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	subscriptionv1 "github.com/oatsmoke/20250905/api/subscription/v1"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type Server struct {
+	port       string
+	grpcServer *grpc.Server
+}
+
+func New(port string, subscriptionServer *rpc.SubscriptionServer) *Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(rpc.UnaryAuth, rpc.UnaryLogging, rpc.UnaryErrors),
+		grpc.ChainStreamInterceptor(rpc.StreamAuth, rpc.StreamLogging, rpc.StreamErrors),
+	)
+	subscriptionv1.RegisterSubscriptionServiceServer(grpcServer, subscriptionServer)
+	reflection.Register(grpcServer)
+
+	return &Server{
+		port:       port,
+		grpcServer: grpcServer,
+	}
+}
+
+func (s *Server) Run() {
+	listener, err := net.Listen("tcp", s.port)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	logger.Info(fmt.Sprintf("grpc server start on %s", s.port))
+}
+
+// Stop lets the calls in flight finish until ctx is done, then closes the remaining connections.
+func (s *Server) Stop(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		<-stopped
+	}
+
+	logger.Info("grpc server stop")
+}
@@ -0,0 +1,66 @@
+package http_client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var errNotPublic = errors.New("address is not public")
+
+// NewPublic returns a client for the addresses chosen by users. It only connects to public addresses, checked on every
+// dial after the name is resolved, so that neither the address nor a redirect nor a DNS answer reaches the internal
+// network, and only follows redirects to https.
+func NewPublic(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !Public(addrPort.Addr()) {
+				return errNotPublic
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on behalf of the client, out of reach of the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s: scheme is not https", req.URL.Redacted())
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+
+			return nil
+		},
+	}
+}
+
+// Public reports whether the address may be reached by the clients of NewPublic: it is not loopback, private,
+// link-local, shared, multicast or unspecified.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, private to the provider network.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
@@ -0,0 +1,89 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+// Run calls fn only while this process holds the session advisory lock for name, so a single replica runs it.
+// Other replicas retry every interval and take over when the leader's connection goes away. The lock is held on a
+// connection of its own to dsn rather than one of the pool, which the leaders would otherwise keep from the requests.
+func Run(ctx context.Context, dsn string, name string, interval time.Duration, fn func(ctx context.Context)) {
+	for {
+		if err := lead(ctx, dsn, name, interval, fn); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func lead(ctx context.Context, dsn string, name string, interval time.Duration, fn func(ctx context.Context)) error {
+	var locked bool
+	const (
+		lockQuery   = `SELECT pg_try_advisory_lock(hashtext($1));`
+		unlockQuery = `SELECT pg_advisory_unlock(hashtext($1));`
+	)
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if err := conn.QueryRow(ctx, lockQuery, name).Scan(&locked); err != nil {
+		return err
+	}
+
+	if !locked {
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("leader of %s", name))
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-ticker.C:
+				if err := conn.Ping(leaderCtx); err != nil {
+					if leaderCtx.Err() == nil {
+						logger.Error(fmt.Errorf("leader of %s lost connection: %w", name, err))
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	fn(leaderCtx)
+	cancel()
+	wg.Wait()
+
+	if _, err := conn.Exec(context.WithoutCancel(ctx), unlockQuery, name); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("leadership of %s released", name))
+	return nil
+}
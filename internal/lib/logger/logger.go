@@ -16,6 +16,10 @@ func Info(msg string) {
 	slog.Info(fmt.Sprintf("[%s]: %s", getFuncName(), msg))
 }
 
+func Error(err error) {
+	slog.Error(fmt.Sprintf("[%s]: %v", getFuncName(), err))
+}
+
 func HttpError(w http.ResponseWriter, err error, status int) {
 	slog.Error(fmt.Sprintf("[%s]: %v", getFuncName(), err))
 	http.Error(w, err.Error(), status)
@@ -26,3 +30,7 @@ func getFuncName() string {
 	funcName := runtime.FuncForPC(pc).Name()
 	return funcName
 }
+
+func Audit(msg string, args ...any) {
+	slog.Warn(fmt.Sprintf("[%s]: %s", getFuncName(), msg), append([]any{"audit", true}, args...)...)
+}
@@ -0,0 +1,138 @@
+// Package middleware composes the HTTP middlewares applied to groups of routes.
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/lib/request_id"
+)
+
+type Middleware func(next http.Handler) http.Handler
+
+// Chain is a sequence of middlewares, the first of them outermost.
+type Chain []Middleware
+
+func New(middlewares ...Middleware) Chain {
+	return middlewares
+}
+
+// Append returns a chain of the middlewares of c followed by middlewares, leaving c as it is.
+func (c Chain) Append(middlewares ...Middleware) Chain {
+	return append(slices.Clip(c), middlewares...)
+}
+
+// Then wraps h in the middlewares of the chain.
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+
+	return h
+}
+
+// Recovery answers 500 to a request whose handler panics, instead of dropping the connection, and logs the panic
+// with its stack, which the client does not see.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// The server aborts the response without logging it.
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			logger.Error(fmt.Errorf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, v, debug.Stack()))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Logging logs the method, path, status and duration of every request with its request id.
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		logger.Info(fmt.Sprintf("http %s %s %d in %s, request: %s",
+			r.Method, r.URL.Path, sw.status, time.Since(start), request_id.FromContext(r.Context())))
+	})
+}
+
+// statusWriter records the status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server, which the event stream flushes.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+var (
+	corsMethods = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}, ", ")
+	corsHeaders = strings.Join([]string{
+		"Content-Type", auth.UserIdHeader, auth.UserRoleHeader, request_id.Header, "Last-Event-ID", "If-None-Match",
+	}, ", ")
+	corsExposedHeaders = strings.Join([]string{
+		request_id.Header, "ETag", "Deprecation", "Sunset", "Link",
+	}, ", ")
+)
+
+// CORS lets browser pages of the allowed origins call the routes: it answers preflight requests and adds the
+// Access-Control headers to the responses. An origin "*" allows any origin, no origins turn CORS off.
+func CORS(origins []string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(slices.Contains(origins, origin) || slices.Contains(origins, "*")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", corsMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
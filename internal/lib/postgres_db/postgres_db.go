@@ -7,8 +7,16 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func Connect(ctx context.Context, dsn string) *pgxpool.Pool {
-	connectDB, err := pgxpool.New(ctx, dsn)
+// Connect opens a pool of at most maxConns connections. The stream of the outbox holds one of them for as long as
+// it listens, so the pool needs room for it next to the requests and the workers.
+func Connect(ctx context.Context, dsn string, maxConns int) *pgxpool.Pool {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.MaxConns = int32(maxConns)
+
+	connectDB, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,59 @@
+package request_id
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const Header = "X-Request-Id"
+
+// maxLength bounds the request ids taken from clients, which are written to the logs and the audit log.
+const maxLength = 64
+
+type requestIdKey struct{}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func FromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(Header)
+		if !Valid(requestId) {
+			requestId = New()
+		}
+
+		w.Header().Set(Header, requestId)
+		next.ServeHTTP(w, r.WithContext(WithRequestId(r.Context(), requestId)))
+	})
+}
+
+// New returns a random request id for requests that do not carry one.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether a request id sent by a client can be used as is: it is not empty and has at most 64 printable
+// ASCII characters. Other request ids are replaced with a new one.
+func Valid(requestId string) bool {
+	if requestId == "" || len(requestId) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < ' ' || requestId[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
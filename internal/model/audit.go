@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionPause   = "pause"
+	ActionResume  = "resume"
+	ActionCancel  = "cancel"
+	ActionConvert = "convert"
+	ActionEnd     = "end"
+
+	ActionAddDiscount    = "add_discount"
+	ActionRemoveDiscount = "remove_discount"
+	ActionAddMember      = "add_member"
+	ActionRemoveMember   = "remove_member"
+	ActionSchedulePrice  = "schedule_price"
+	ActionRemovePrice    = "remove_price"
+)
+
+type AuditRecord struct {
+	ID             int64           `json:"id"`
+	SubscriptionId int64           `json:"subscription_id"`
+	Actor          string          `json:"actor"`
+	Action         string          `json:"action"`
+	Before         json.RawMessage `json:"before"`
+	After          json.RawMessage `json:"after"`
+	RequestId      string          `json:"request_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	Actor string
+	From  *time.Time
+	To    *time.Time
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const (
+	PeriodMonth = "month"
+	PeriodYear  = "year"
+)
+
+const DefaultCurrency = "RUB"
+
+var DefaultThresholds = []int32{80, 100}
+
+// Budget is a spending limit of a user per period, for all services, for one service when ServiceName is set, or for
+// the services of a category when Category is set.
+type Budget struct {
+	ID          int64     `json:"id"`
+	UserId      string    `json:"user_id"`
+	ServiceName string    `json:"service_name"`
+	Category    string    `json:"category,omitempty"`
+	Services    []string  `json:"services,omitempty"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Period      string    `json:"period"`
+	Thresholds  []int32   `json:"thresholds"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type BudgetStatus struct {
+	Budget      *Budget `json:"budget"`
+	PeriodStart string  `json:"period_start"`
+	PeriodEnd   string  `json:"period_end"`
+	Spent       int64   `json:"spent"`
+	PercentUsed float64 `json:"percent_used"`
+	Crossed     []int32 `json:"crossed"`
+}
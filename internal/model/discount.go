@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// MaxDiscountPeriods bounds the number of billing periods a discount lasts.
+const MaxDiscountPeriods = 120
+
+// Discount reduces the price of a subscription by a percentage or a fixed amount, at most to zero, for the Periods
+// months from StartDate. It does not apply to the months that are not billed, paused or in trial.
+type Discount struct {
+	ID             int64     `json:"id"`
+	SubscriptionId int64     `json:"subscription_id"`
+	Kind           string    `json:"kind"`
+	Value          int64     `json:"value"`
+	StartDate      string    `json:"start_date"`
+	Periods        int32     `json:"periods"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ScheduledPrice replaces the price of a subscription in the spending forecast from the month StartDate until the next
+// scheduled price. The price of the subscription itself is projected until the first of them.
+type ScheduledPrice struct {
+	ID             int64     `json:"id"`
+	SubscriptionId int64     `json:"subscription_id"`
+	Price          int64     `json:"price"`
+	StartDate      string    `json:"start_date"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PriceChange is the price billed from Month until the next change.
+type PriceChange struct {
+	Month string `json:"month"`
+	Price int64  `json:"price"`
+}
+
+// SubscriptionDetail is a subscription with the schedule of the price it is billed each month.
+type SubscriptionDetail struct {
+	ExternalData
+	EffectivePrices []*PriceChange `json:"effective_prices,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Member shares the cost of a subscription with its owner from StartDate until EndDate. A member with a Share pays
+// that percentage of the monthly price; the rest is split equally between the owner and the members without one.
+type Member struct {
+	ID             int64     `json:"id"`
+	SubscriptionId int64     `json:"subscription_id"`
+	UserId         string    `json:"user_id"`
+	Share          *int32    `json:"share,omitempty"`
+	StartDate      string    `json:"start_date"`
+	EndDate        string    `json:"end_date,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
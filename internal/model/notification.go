@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ChannelLog     = "log"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelNone    = "none"
+)
+
+const (
+	ReminderRenewal = "renewal"
+	ReminderEnd     = "end"
+)
+
+const (
+	NotificationSubscriptionCreated = "subscription_created"
+	NotificationMonthlySummary      = "monthly_summary"
+)
+
+const DefaultLocale = "en"
+
+type Preference struct {
+	UserId           string `json:"user_id"`
+	Channel          string `json:"channel"`
+	Address          string `json:"address"`
+	RenewalReminders bool   `json:"renewal_reminders"`
+	EndReminders     bool   `json:"end_reminders"`
+	MonthlySummaries bool   `json:"monthly_summaries"`
+	Locale           string `json:"locale"`
+}
+
+type Reminder struct {
+	SubscriptionId int64
+	UserId         string
+	ServiceName    string
+	Price          int64
+	Kind           string
+	DueDate        time.Time
+	Channel        string
+	Address        string
+	Locale         string
+}
+
+type Email struct {
+	ID       int64
+	UserId   string
+	Kind     string
+	Locale   string
+	Address  string
+	Data     json.RawMessage
+	Attempts int
+}
@@ -0,0 +1,61 @@
+package model
+
+// MonthlyCost is the spend of one calendar month, formatted as MM-YYYY like subscription dates.
+type MonthlyCost struct {
+	Month string `json:"month"`
+	Total int64  `json:"total"`
+}
+
+type Forecast struct {
+	UserId string         `json:"user_id"`
+	Months []*MonthlyCost `json:"months"`
+	Total  int64          `json:"total"`
+}
+
+// MRRMonth is the monthly recurring revenue of a month and its movements against the previous month,
+// computed per user: new revenue comes from users without subscriptions in the previous month and churned revenue
+// from users without subscriptions in this month.
+type MRRMonth struct {
+	Month       string `json:"month"`
+	Subscribers int64  `json:"subscribers"`
+	MRR         int64  `json:"mrr"`
+	New         int64  `json:"new"`
+	Expansion   int64  `json:"expansion"`
+	Contraction int64  `json:"contraction"`
+	Churned     int64  `json:"churned"`
+}
+
+type ServiceSubscribers struct {
+	Month       string `json:"month"`
+	ServiceName string `json:"service_name"`
+	Subscribers int64  `json:"subscribers"`
+}
+
+// Cohort holds the users whose first subscription started in the month and how many of them still had an active
+// subscription each month after, starting with the cohort month itself.
+type Cohort struct {
+	Month     string    `json:"month"`
+	Size      int64     `json:"size"`
+	Retained  []int64   `json:"retained"`
+	Retention []float64 `json:"retention"`
+}
+
+// CostMismatch is a monthly cost change that differs between subscription_monthly_costs and the subscriptions.
+type CostMismatch struct {
+	UserId      string `json:"user_id"`
+	ServiceName string `json:"service_name"`
+	Month       string `json:"month"`
+	Expected    int64  `json:"expected"`
+	Actual      int64  `json:"actual"`
+}
+
+// Overlap is a pair of live subscriptions of a user to the same service whose periods intersect, with the months
+// they share. EndDate is empty when both are open-ended.
+type Overlap struct {
+	UserId         string `json:"user_id"`
+	ServiceName    string `json:"service_name"`
+	SubscriptionId int64  `json:"subscription_id"`
+	OverlappingId  int64  `json:"overlapping_id"`
+	StartDate      string `json:"start_date"`
+	EndDate        string `json:"end_date,omitempty"`
+}
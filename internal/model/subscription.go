@@ -2,20 +2,78 @@ package model
 
 import "time"
 
+// Subscription statuses. Ended is not stored: a subscription that has not been cancelled is ended once its end date
+// has passed.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusEnded     = "ended"
+	StatusTrial     = "trial"
+)
+
+// Cancellation takes effect now, leaving the current month unbilled, or at the end of the current month.
+const (
+	CancelNow       = "now"
+	CancelPeriodEnd = "period_end"
+)
+
 type Subscription struct {
-	ID          int64
-	ServiceName string
-	Price       int64
-	UserId      string
-	StartDate   time.Time
-	EndDate     *time.Time
+	ID           int64
+	ServiceName  string
+	Price        int64
+	UserId       string
+	StartDate    time.Time
+	EndDate      *time.Time
+	DeletedAt    *time.Time
+	Status       string
+	TrialEndDate *time.Time
 }
 
 type ExternalData struct {
-	ID          int64  `json:"id"`
-	ServiceName string `json:"service_name"`
-	Price       int64  `json:"price"`
-	UserId      string `json:"user_id"`
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date"`
+	ID           int64  `json:"id"`
+	ServiceName  string `json:"service_name"`
+	Price        int64  `json:"price"`
+	UserId       string `json:"user_id"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	DeletedAt    string `json:"deleted_at,omitempty"`
+	Status       string `json:"status,omitempty"`
+	TrialEndDate string `json:"trial_end_date,omitempty"`
+}
+
+// Filter selects subscriptions. List returns at most Limit of them, when it is set, with IDs above AfterId, and only
+// those of UserIds when it is not nil.
+type Filter struct {
+	UserId         string
+	UserIds        []string
+	IncludeDeleted bool
+	AsOf           *time.Time
+	TrialEndsBy    *time.Time
+	Limit          int
+	AfterId        int64
+}
+
+// SubscriptionV2 is a subscription as version 2 of the API represents it: months are ISO 8601 (YYYY-MM) and the
+// service is an object.
+type SubscriptionV2 struct {
+	ID           int64     `json:"id"`
+	Service      ServiceV2 `json:"service"`
+	Price        int64     `json:"price"`
+	UserId       string    `json:"user_id"`
+	StartDate    string    `json:"start_date"`
+	EndDate      string    `json:"end_date,omitempty"`
+	DeletedAt    string    `json:"deleted_at,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	TrialEndDate string    `json:"trial_end_date,omitempty"`
+}
+
+type ServiceV2 struct {
+	Name string `json:"name"`
+}
+
+// SubscriptionDetailV2 is SubscriptionDetail in version 2 of the API.
+type SubscriptionDetailV2 struct {
+	SubscriptionV2
+	EffectivePrices []*PriceChange `json:"effective_prices,omitempty"`
 }
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventSubscriptionCreated      = "subscription.created"
+	EventSubscriptionUpdated      = "subscription.updated"
+	EventSubscriptionDeleted      = "subscription.deleted"
+	EventSubscriptionEnded        = "subscription.ended"
+	EventSubscriptionPriceChanged = "subscription.price_changed"
+	EventSubscriptionPaused       = "subscription.paused"
+	EventSubscriptionResumed      = "subscription.resumed"
+	EventSubscriptionCancelled    = "subscription.cancelled"
+	EventTrialConverted           = "subscription.trial_converted"
+	EventDiscountAdded            = "subscription.discount_added"
+	EventDiscountRemoved          = "subscription.discount_removed"
+	EventMemberAdded              = "subscription.member_added"
+	EventMemberRemoved            = "subscription.member_removed"
+	EventPriceScheduled           = "subscription.price_scheduled"
+	EventPriceRemoved             = "subscription.price_removed"
+	EventBudgetThresholdCrossed   = "budget.threshold_crossed"
+	EventAll                      = "*"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookId     int64           `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	URL           string          `json:"-"`
+	Secret        string          `json:"-"`
+}
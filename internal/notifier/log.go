@@ -0,0 +1,19 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (n *Log) Notify(_ context.Context, notification *Notification) error {
+	logger.Info(fmt.Sprintf("notify user %s: %s", notification.UserId, notification.Subject))
+	return nil
+}
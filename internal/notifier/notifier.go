@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+type Notification struct {
+	UserId  string         `json:"user_id"`
+	Kind    string         `json:"kind"`
+	Channel string         `json:"-"`
+	Address string         `json:"-"`
+	Locale  string         `json:"locale"`
+	Subject string         `json:"subject"`
+	Body    string         `json:"body"`
+	HTML    string         `json:"-"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// Router sends each notification through the notifier registered for its channel.
+type Router map[string]Notifier
+
+func (r Router) Notify(ctx context.Context, notification *Notification) error {
+	notifier, ok := r[notification.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel %q", notification.Channel)
+	}
+
+	return notifier.Notify(ctx, notification)
+}
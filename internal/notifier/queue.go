@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Enqueuer interface {
+	Enqueue(ctx context.Context, email *model.Email) error
+}
+
+// Queue stores email notifications for the email worker, which renders and sends them with retries.
+type Queue struct {
+	emails Enqueuer
+}
+
+func NewQueue(emails Enqueuer) *Queue {
+	return &Queue{
+		emails: emails,
+	}
+}
+
+func (n *Queue) Notify(ctx context.Context, notification *Notification) error {
+	data, err := json.Marshal(notification.Data)
+	if err != nil {
+		return err
+	}
+
+	return n.emails.Enqueue(ctx, &model.Email{
+		UserId:  notification.UserId,
+		Kind:    notification.Kind,
+		Locale:  notification.Locale,
+		Address: notification.Address,
+		Data:    data,
+	})
+}
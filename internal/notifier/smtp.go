@@ -0,0 +1,194 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+// SMTP sends rendered notifications as multipart text and HTML emails.
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	tls      string
+}
+
+func NewSMTP(addr, username, password, from, tlsMode string) (*SMTP, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tlsMode {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", tlsMode)
+	}
+
+	return &SMTP{
+		addr:     addr,
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+		tls:      tlsMode,
+	}, nil
+}
+
+func (n *SMTP) Notify(ctx context.Context, notification *Notification) error {
+	msg, err := n.message(notification)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.tls == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", n.addr)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(notification.Address); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (n *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.tls == TLSImplicit {
+		conn = tls.Client(conn, &tls.Config{ServerName: n.host})
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// message builds a multipart/alternative email with quoted-printable text and HTML parts.
+func (n *SMTP) message(notification *Notification) ([]byte, error) {
+	if _, err := mail.ParseAddress(notification.Address); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	headers := []struct{ key, value string }{
+		{"From", n.from},
+		{"To", notification.Address},
+		{"Subject", mime.QEncoding.Encode("UTF-8", notification.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), n.host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", parts.Boundary())},
+	}
+	var header bytes.Buffer
+	for _, h := range headers {
+		fmt.Fprintf(&header, "%s: %s\r\n", h.key, h.value)
+	}
+	header.WriteString("\r\n")
+
+	if err := writePart(parts, "text/plain", notification.Body); err != nil {
+		return nil, err
+	}
+
+	if notification.HTML != "" {
+		if err := writePart(parts, "text/html", notification.HTML); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(header.Bytes(), buf.Bytes()...), nil
+}
+
+func writePart(parts *multipart.Writer, contentType, content string) error {
+	w, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
@@ -0,0 +1,116 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/oatsmoke/20250905/internal/notifier/smtptest"
+)
+
+func TestSMTPDeliversRenderedNotification(t *testing.T) {
+	server, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	smtpNotifier, err := NewSMTP(server.Addr, "", "", "noreply@example.com", TLSNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notification := &Notification{
+		Kind:    "renewal",
+		Address: "user@example.com",
+		Locale:  "ru",
+		Data: map[string]any{
+			"service_name": "Yandex Plus",
+			"due_date":     "01-11-2025",
+			"price":        400,
+		},
+	}
+	if err := Render(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := smtpNotifier.Notify(context.Background(), notification); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if messages[0].From != "noreply@example.com" {
+		t.Errorf("got sender %q, want noreply@example.com", messages[0].From)
+	}
+	if len(messages[0].To) != 1 || messages[0].To[0] != "user@example.com" {
+		t.Errorf("got recipients %q, want user@example.com", messages[0].To)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].Data + "\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Yandex Plus продлевается 01-11-2025"; subject != want {
+		t.Errorf("got subject %q, want %q", subject, want)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(content)
+	}
+
+	if want := "Ваша подписка Yandex Plus продлевается 01-11-2025 за 400."; !strings.Contains(parts["text/plain"], want) {
+		t.Errorf("got text %q, want it to contain %q", parts["text/plain"], want)
+	}
+	if want := "<b>Yandex Plus</b>"; !strings.Contains(parts["text/html"], want) {
+		t.Errorf("got html %q, want it to contain %q", parts["text/html"], want)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	for locale, want := range map[string]bool{
+		"en":    true,
+		"ru":    true,
+		"":      false,
+		".":     false,
+		"..":    false,
+		"ru/..": false,
+		"de":    false,
+	} {
+		if got := Supports(locale); got != want {
+			t.Errorf("Supports(%q) = %t, want %t", locale, got, want)
+		}
+	}
+}
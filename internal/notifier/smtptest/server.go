@@ -0,0 +1,158 @@
+// Package smtptest provides a local SMTP server that records received messages, for use in tests and local runs
+// with SMTP_TLS=none.
+package smtptest
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+type Server struct {
+	Addr string
+
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on a random local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		listener: listener,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+func (s *Server) handle(conn *textproto.Conn) {
+	var msg Message
+	reply := func(code int, text string) bool {
+		return conn.PrintfLine("%d %s", code, text) == nil
+	}
+
+	if !reply(220, "localhost smtptest") {
+		return
+	}
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if conn.PrintfLine("250-localhost") != nil || !reply(250, "AUTH PLAIN") {
+				return
+			}
+		case "HELO", "NOOP":
+			if !reply(250, "OK") {
+				return
+			}
+		case "AUTH":
+			if !reply(235, "Authentication succeeded") {
+				return
+			}
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			if !reply(250, "OK") {
+				return
+			}
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			if !reply(250, "OK") {
+				return
+			}
+		case "DATA":
+			if !reply(354, "End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			lines, err := conn.ReadDotLines()
+			if err != nil {
+				return
+			}
+			msg.Data = strings.Join(lines, "\r\n")
+
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+
+			if !reply(250, "OK") {
+				return
+			}
+		case "RSET":
+			msg = Message{}
+			if !reply(250, "OK") {
+				return
+			}
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			if !reply(502, "Command not implemented") {
+				return
+			}
+		}
+	}
+}
+
+// address extracts the mailbox from a MAIL FROM or RCPT TO argument.
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr = strings.TrimSpace(addr)
+	if i := strings.IndexByte(addr, ' '); i >= 0 {
+		addr = addr[:i]
+	}
+
+	return strings.Trim(addr, "<>")
+}
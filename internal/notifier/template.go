@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// locales are the directories of templates. A locale is looked up in this list rather than on the file system, so that
+// a locale such as ".." never names a directory.
+var locales = []string{"en", "ru"}
+
+// Supports reports whether notifications can be rendered in the locale.
+func Supports(locale string) bool {
+	return slices.Contains(locales, locale)
+}
+
+// Render fills the subject, the text body and the HTML body of the notification from the templates of its kind,
+// falling back to the default locale when the notification locale has none.
+func Render(notification *Notification) error {
+	dir := path.Join("templates", notification.Locale)
+	if !Supports(notification.Locale) {
+		dir = path.Join("templates", model.DefaultLocale)
+	}
+
+	subject, err := renderText(path.Join(dir, notification.Kind+".subject.tmpl"), notification.Data)
+	if err != nil {
+		return err
+	}
+
+	body, err := renderText(path.Join(dir, notification.Kind+".txt.tmpl"), notification.Data)
+	if err != nil {
+		return err
+	}
+
+	html, err := renderHTML(path.Join(dir, notification.Kind+".html.tmpl"), notification.Data)
+	if err != nil {
+		return err
+	}
+
+	notification.Subject = strings.TrimSpace(subject)
+	notification.Body = body
+	notification.HTML = html
+	return nil
+}
+
+func renderText(name string, data any) (string, error) {
+	tmpl, err := template.New(path.Base(name)).Option("missingkey=zero").ParseFS(templates, name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func renderHTML(name string, data any) (string, error) {
+	tmpl, err := htmltemplate.New(path.Base(name)).Option("missingkey=zero").ParseFS(templates, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/signature"
+)
+
+// Webhook posts notifications as JSON to the address chosen by the user, signed like subscription webhooks.
+type Webhook struct {
+	client *http.Client
+	secret string
+}
+
+func NewWebhook(client *http.Client, secret string) *Webhook {
+	return &Webhook{
+		client: client,
+		secret: secret,
+	}
+}
+
+func (n *Webhook) Notify(ctx context.Context, notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(signature.TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(signature.Header, signature.Sign(n.secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
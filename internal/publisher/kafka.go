@@ -0,0 +1,47 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/segmentio/kafka-go"
+)
+
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+// NewKafka keys messages by subscription id, so all events of a subscription land in one partition in order.
+func NewKafka(brokers []string, topic string) *Kafka {
+	return &Kafka{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			MaxAttempts:  1,
+		},
+	}
+}
+
+func (p *Kafka) Publish(ctx context.Context, envelope *events.Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(envelope.Key),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(envelope.Type)},
+			{Key: "id", Value: []byte(strconv.FormatInt(envelope.ID, 10))},
+		},
+	})
+}
+
+func (p *Kafka) Close() error {
+	return p.writer.Close()
+}
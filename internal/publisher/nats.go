@@ -0,0 +1,50 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"github.com/oatsmoke/20250905/internal/events"
+)
+
+type Nats struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNats(url, subject string) (*Nats, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Nats{
+		conn:    conn,
+		subject: subject,
+	}, nil
+}
+
+// Publish sends the event to "<subject>.<type>" and waits for the server to acknowledge the flush.
+// The Nats-Msg-Id header lets JetStream streams drop redelivered duplicates.
+func (p *Nats) Publish(ctx context.Context, envelope *events.Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.subject + "." + envelope.Type)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatInt(envelope.ID, 10))
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *Nats) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,32 @@
+package publisher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/env"
+)
+
+const (
+	KindStdout = "stdout"
+	KindFile   = "file"
+	KindNats   = "nats"
+	KindKafka  = "kafka"
+)
+
+func New(kind string) (events.Publisher, error) {
+	switch kind {
+	case KindStdout:
+		return NewWriter(os.Stdout), nil
+	case KindFile:
+		return NewFile(env.GetEventsFile())
+	case KindNats:
+		return NewNats(env.GetNatsUrl(), env.GetNatsSubject())
+	case KindKafka:
+		return NewKafka(strings.Split(env.GetKafkaBrokers(), ","), env.GetKafkaTopic()), nil
+	default:
+		return nil, fmt.Errorf("unknown events publisher %q", kind)
+	}
+}
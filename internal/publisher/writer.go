@@ -0,0 +1,59 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/oatsmoke/20250905/internal/events"
+)
+
+// Writer publishes events as JSON lines, e.g. to stdout or a local file.
+type Writer struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	file    *os.File
+}
+
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+func NewFile(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		encoder: json.NewEncoder(file),
+		file:    file,
+	}, nil
+}
+
+func (p *Writer) Publish(_ context.Context, envelope *events.Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.encoder.Encode(envelope); err != nil {
+		return err
+	}
+
+	if p.file != nil {
+		return p.file.Sync()
+	}
+
+	return nil
+}
+
+func (p *Writer) Close() error {
+	if p.file != nil {
+		return p.file.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/lib/request_id"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+const systemActor = "system"
+
+func (r *SubscriptionRepository) History(ctx context.Context, subscriptionId int64, filter *model.AuditFilter) ([]*model.AuditRecord, error) {
+	var records []*model.AuditRecord
+	const query = `
+		SELECT id, subscription_id, actor, action, before, after, request_id, created_at
+		FROM subscription_audit
+		WHERE subscription_id = $1
+		  AND ($2 = '' OR actor = $2)
+		  AND ($3::timestamptz IS NULL OR created_at >= $3)
+		  AND ($4::timestamptz IS NULL OR created_at < $4)
+		ORDER BY created_at, id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, subscriptionId, filter.Actor, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record := new(model.AuditRecord)
+		if err := rows.Scan(
+			&record.ID,
+			&record.SubscriptionId,
+			&record.Actor,
+			&record.Action,
+			&record.Before,
+			&record.After,
+			&record.RequestId,
+			&record.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d audit records of subscription with id %d listed", len(records), subscriptionId))
+	return records, nil
+}
+
+func snapshot(ctx context.Context, tx pgx.Tx, subscriptionId int64) ([]byte, error) {
+	var before []byte
+	const query = `
+		SELECT to_jsonb(subscriptions.*)
+		FROM subscriptions
+		WHERE id = $1
+		FOR UPDATE;`
+
+	if err := tx.QueryRow(ctx, query, subscriptionId).Scan(&before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err_msg.NoRowsAffected
+		}
+		return nil, err
+	}
+
+	return before, nil
+}
+
+func audit(ctx context.Context, tx pgx.Tx, subscriptionId int64, action string, before, after []byte) error {
+	const query = `
+		INSERT INTO subscription_audit (subscription_id, actor, action, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6);`
+
+	actor := systemActor
+	if a, ok := auth.FromContext(ctx); ok {
+		actor = a.ID
+	}
+
+	_, err := tx.Exec(ctx, query, subscriptionId, actor, action, before, after, request_id.FromContext(ctx))
+	return err
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type BudgetRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewBudgetRepository(postgresDB *pgxpool.Pool) *BudgetRepository {
+	return &BudgetRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+func (r *BudgetRepository) Create(ctx context.Context, budget *model.Budget) error {
+	const query = `
+		INSERT INTO budgets (user_id, service_name, category, services, amount, currency, period, thresholds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at;`
+
+	if err := r.postgresDB.QueryRow(
+		ctx,
+		query,
+		budget.UserId,
+		budget.ServiceName,
+		budget.Category,
+		budget.Services,
+		budget.Amount,
+		budget.Currency,
+		budget.Period,
+		budget.Thresholds,
+	).Scan(&budget.ID, &budget.CreatedAt); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("budget with id %d created", budget.ID))
+	return nil
+}
+
+func (r *BudgetRepository) Read(ctx context.Context, userId string, budgetId int64) (*model.Budget, error) {
+	budget := new(model.Budget)
+	const query = `
+		SELECT id, user_id, service_name, category, services, amount, currency, period, thresholds, created_at
+		FROM budgets
+		WHERE id = $1
+		  AND user_id = $2;`
+
+	if err := scanBudget(r.postgresDB.QueryRow(ctx, query, budgetId, userId), budget); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err_msg.NoRowsAffected
+		}
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("budget with id %d read", budgetId))
+	return budget, nil
+}
+
+func (r *BudgetRepository) Update(ctx context.Context, budget *model.Budget) error {
+	const query = `
+		UPDATE budgets
+		SET service_name = $3, category = $4, services = $5, amount = $6, currency = $7, period = $8, thresholds = $9
+		WHERE id = $1
+		  AND user_id = $2;`
+
+	tag, err := r.postgresDB.Exec(
+		ctx,
+		query,
+		budget.ID,
+		budget.UserId,
+		budget.ServiceName,
+		budget.Category,
+		budget.Services,
+		budget.Amount,
+		budget.Currency,
+		budget.Period,
+		budget.Thresholds,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return err_msg.NoRowsAffected
+	}
+
+	logger.Info(fmt.Sprintf("budget with id %d updated", budget.ID))
+	return nil
+}
+
+func (r *BudgetRepository) Delete(ctx context.Context, userId string, budgetId int64) error {
+	const query = `
+		DELETE FROM budgets
+		WHERE id = $1
+		  AND user_id = $2;`
+
+	tag, err := r.postgresDB.Exec(ctx, query, budgetId, userId)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return err_msg.NoRowsAffected
+	}
+
+	logger.Info(fmt.Sprintf("budget with id %d deleted", budgetId))
+	return nil
+}
+
+// List returns the budgets of the user, or of all users when userId is empty.
+func (r *BudgetRepository) List(ctx context.Context, userId string) ([]*model.Budget, error) {
+	var budgets []*model.Budget
+	const query = `
+		SELECT id, user_id, service_name, category, services, amount, currency, period, thresholds, created_at
+		FROM budgets
+		WHERE ($1 = '' OR user_id = $1)
+		ORDER BY id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		budget := new(model.Budget)
+		if err := scanBudget(rows, budget); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d budgets listed", len(budgets)))
+	return budgets, nil
+}
+
+// ListOf lists the budgets of the users.
+func (r *BudgetRepository) ListOf(ctx context.Context, userIds []string) ([]*model.Budget, error) {
+	var budgets []*model.Budget
+	const query = `
+		SELECT id, user_id, service_name, category, services, amount, currency, period, thresholds, created_at
+		FROM budgets
+		WHERE user_id = ANY ($1)
+		ORDER BY id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, userIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		budget := new(model.Budget)
+		if err := scanBudget(rows, budget); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d budgets of %d users listed", len(budgets), len(userIds)))
+	return budgets, nil
+}
+
+// Alert records that the status crossed the threshold and raises the event, once per budget period and threshold.
+// It reports whether the alert is new.
+func (r *BudgetRepository) Alert(ctx context.Context, status *model.BudgetStatus, threshold int32) (bool, error) {
+	const query = `
+		INSERT INTO budget_alerts (budget_id, period_start, threshold, spent)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING;`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, status.Budget.ID, status.PeriodStart, threshold, status.Spent)
+	if err != nil {
+		return false, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	event := &events.BudgetThresholdCrossed{
+		Budget:      *status.Budget,
+		Threshold:   threshold,
+		PeriodStart: status.PeriodStart,
+		Spent:       status.Spent,
+		PercentUsed: status.PercentUsed,
+	}
+
+	if err := appendOutbox(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+
+	if err := enqueueWebhooks(ctx, tx, event.Type(), "alert", payload); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	logger.Info(fmt.Sprintf("budget with id %d crossed %d%% for %s", status.Budget.ID, threshold, status.PeriodStart))
+	return true, nil
+}
+
+func scanBudget(row pgx.Row, budget *model.Budget) error {
+	return row.Scan(
+		&budget.ID,
+		&budget.UserId,
+		&budget.ServiceName,
+		&budget.Category,
+		&budget.Services,
+		&budget.Amount,
+		&budget.Currency,
+		&budget.Period,
+		&budget.Thresholds,
+		&budget.CreatedAt,
+	)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// liveMonthlyCosts computes the monthly cost changes from the subscriptions with subscription_costs, the function
+// the triggers of the subscriptions, their pauses, discounts and members refresh subscription_monthly_costs with: a
+// subscription adds its price from the month its trial ends, or its start month without a trial, and takes it away
+// from its end month, each of its pauses takes the price away for the months it covers, its discounts take their
+// reduction away for the billed months they cover, and its members take their shares over from the owner.
+const liveMonthlyCosts = `
+		WITH live AS (
+		    SELECT c.user_id, c.service_name, c.month, sum(c.delta) AS delta
+		    FROM subscriptions s
+		             CROSS JOIN LATERAL subscription_costs(s.id) c
+		    WHERE s.deleted_at IS NULL
+		    GROUP BY c.user_id, c.service_name, c.month
+		    HAVING sum(c.delta) <> 0
+		)`
+
+type CostRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewCostRepository(postgresDB *pgxpool.Pool) *CostRepository {
+	return &CostRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+// Rebuild recomputes subscription_cost_changes and subscription_monthly_costs from the subscriptions, their pauses,
+// discounts and members, blocking their changes meanwhile.
+func (r *CostRepository) Rebuild(ctx context.Context) (int64, error) {
+	const (
+		lockQuery = `
+			LOCK TABLE subscriptions, subscription_pauses, subscription_discounts, subscription_members IN SHARE MODE;`
+		deleteChangesQuery = `
+			DELETE FROM subscription_cost_changes;`
+		deleteQuery = `
+			DELETE FROM subscription_monthly_costs;`
+		changesQuery = `
+			INSERT INTO subscription_cost_changes (subscription_id, user_id, service_name, month, delta)
+			SELECT s.id, c.user_id, c.service_name, c.month, c.delta
+			FROM subscriptions s
+			         CROSS JOIN LATERAL subscription_costs(s.id) c
+			WHERE s.deleted_at IS NULL;`
+		insertQuery = liveMonthlyCosts + `
+			INSERT INTO subscription_monthly_costs (user_id, service_name, month, delta)
+			SELECT user_id, service_name, month, delta
+			FROM live;`
+	)
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, lockQuery); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, deleteChangesQuery); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, deleteQuery); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, changesQuery); err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx, insertQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	logger.Info(fmt.Sprintf("%d monthly costs rebuilt", tag.RowsAffected()))
+	return tag.RowsAffected(), nil
+}
+
+// Check compares subscription_monthly_costs with the costs computed from the subscriptions in one snapshot.
+func (r *CostRepository) Check(ctx context.Context) ([]*model.CostMismatch, error) {
+	var mismatches []*model.CostMismatch
+	const query = liveMonthlyCosts + `
+		SELECT coalesce(l.user_id, c.user_id),
+		       coalesce(l.service_name, c.service_name),
+		       to_char(coalesce(l.month, c.month), 'MM-YYYY'),
+		       coalesce(l.delta, 0),
+		       coalesce(c.delta, 0)
+		FROM live l
+		         FULL JOIN subscription_monthly_costs c
+		                   ON c.user_id = l.user_id
+		                       AND c.service_name = l.service_name
+		                       AND c.month = l.month
+		WHERE l.delta IS DISTINCT FROM c.delta
+		ORDER BY 1, 2, coalesce(l.month, c.month);`
+
+	tx, err := r.postgresDB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		mismatch := new(model.CostMismatch)
+		if err := rows.Scan(
+			&mismatch.UserId,
+			&mismatch.ServiceName,
+			&mismatch.Month,
+			&mismatch.Expected,
+			&mismatch.Actual,
+		); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d monthly cost mismatches found", len(mismatches)))
+	return mismatches, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// AddDiscount attaches the discount to the live subscription. The subscription row is locked first, so the monthly
+// costs of the subscription are refreshed one change at a time.
+func (r *SubscriptionRepository) AddDiscount(ctx context.Context, subscription *model.Subscription, discount *model.Discount) error {
+	const query = `
+		INSERT INTO subscription_discounts (subscription_id, kind, value, start_month, periods)
+		VALUES ($1, $2, $3, to_date($4, 'MM-YYYY'), $5)
+		RETURNING id, created_at;`
+
+	discount.SubscriptionId = subscription.ID
+	if err := r.adjust(ctx, subscription.ID, model.ActionAddDiscount, "discount", discount, func(tx pgx.Tx) error {
+		return tx.QueryRow(
+			ctx,
+			query,
+			discount.SubscriptionId,
+			discount.Kind,
+			discount.Value,
+			discount.StartDate,
+			discount.Periods,
+		).Scan(&discount.ID, &discount.CreatedAt)
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("discount with id %d added to subscription with id %d", discount.ID, subscription.ID))
+	return nil
+}
+
+// RemoveDiscount stops applying the discount of the subscription. The discount is kept, so totals as of an earlier
+// time still apply it.
+func (r *SubscriptionRepository) RemoveDiscount(ctx context.Context, subscription *model.Subscription, discountId int64) error {
+	const query = `
+		UPDATE subscription_discounts
+		SET removed_at = now()
+		WHERE id = $1
+		  AND subscription_id = $2
+		  AND removed_at IS NULL
+		RETURNING id, subscription_id, kind, value, to_char(start_month, 'MM-YYYY'), periods, created_at;`
+
+	discount := new(model.Discount)
+	if err := r.adjust(ctx, subscription.ID, model.ActionRemoveDiscount, "discount", discount, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, discountId, subscription.ID).Scan(
+			&discount.ID,
+			&discount.SubscriptionId,
+			&discount.Kind,
+			&discount.Value,
+			&discount.StartDate,
+			&discount.Periods,
+			&discount.CreatedAt,
+		); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return err_msg.NoRowsAffected
+			}
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("discount with id %d removed from subscription with id %d", discountId, subscription.ID))
+	return nil
+}
+
+// Discounts lists the discounts applied to the subscription.
+func (r *SubscriptionRepository) Discounts(ctx context.Context, subscriptionId int64) ([]*model.Discount, error) {
+	var discounts []*model.Discount
+	const query = `
+		SELECT id, subscription_id, kind, value, to_char(start_month, 'MM-YYYY'), periods, created_at
+		FROM subscription_discounts
+		WHERE subscription_id = $1
+		  AND removed_at IS NULL
+		ORDER BY start_month, id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		discount := new(model.Discount)
+		if err := rows.Scan(
+			&discount.ID,
+			&discount.SubscriptionId,
+			&discount.Kind,
+			&discount.Value,
+			&discount.StartDate,
+			&discount.Periods,
+			&discount.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, discount)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d discounts of subscription with id %d listed", len(discounts), subscriptionId))
+	return discounts, nil
+}
+
+// EffectivePrices returns the price billed for the subscription from each month it changes, adding up its cost
+// changes of all the users sharing it: the billed start, pauses, discounts and the end.
+func (r *SubscriptionRepository) EffectivePrices(ctx context.Context, subscriptionId int64) ([]*model.PriceChange, error) {
+	var prices []*model.PriceChange
+	const query = `
+		SELECT to_char(month, 'MM-YYYY'), sum(sum(delta)) OVER (ORDER BY month)
+		FROM subscription_cost_changes
+		WHERE subscription_id = $1
+		GROUP BY month
+		ORDER BY month;`
+
+	rows, err := r.postgresDB.Query(ctx, query, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		price := new(model.PriceChange)
+		if err := rows.Scan(&price.Month, &price.Price); err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// adjust locks the live subscription and applies a change of its discounts or members in one transaction. The
+// change is recorded as action, with the snapshot after it carrying detail under key.
+func (r *SubscriptionRepository) adjust(ctx context.Context, subscriptionId int64, action, key string, detail any, apply func(pgx.Tx) error) error {
+	var before []byte
+	const lockQuery = `
+		SELECT to_jsonb(subscriptions.*)
+		FROM subscriptions
+		WHERE id = $1
+		  AND deleted_at IS NULL
+		FOR UPDATE;`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, lockQuery, subscriptionId).Scan(&before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err_msg.NoRowsAffected
+		}
+		return err
+	}
+
+	if err := apply(tx); err != nil {
+		return err
+	}
+
+	after, err := withDetail(before, key, detail)
+	if err != nil {
+		return err
+	}
+
+	if err := record(ctx, tx, subscriptionId, action, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// withDetail adds detail under key to the snapshot of a subscription.
+func withDetail(snapshot []byte, key string, detail any) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(detail)
+	if err != nil {
+		return nil, err
+	}
+	fields[key] = value
+
+	return json.Marshal(fields)
+}
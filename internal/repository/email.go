@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type EmailRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewEmailRepository(postgresDB *pgxpool.Pool) *EmailRepository {
+	return &EmailRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+func (r *EmailRepository) Enqueue(ctx context.Context, email *model.Email) error {
+	const query = `
+		INSERT INTO email_queue (user_id, kind, locale, address, data)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;`
+
+	if err := r.postgresDB.QueryRow(
+		ctx,
+		query,
+		email.UserId,
+		email.Kind,
+		email.Locale,
+		email.Address,
+		email.Data,
+	).Scan(&email.ID); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("%s email with id %d queued", email.Kind, email.ID))
+	return nil
+}
+
+// EnqueueMonthlySummaries queues one spending summary of the month for every user who receives emails, with the
+// shares of the subscriptions the user owns or shares that are billed that month after their discounts.
+// The dedup key keeps the summary of a month from being queued twice.
+func (r *EmailRepository) EnqueueMonthlySummaries(ctx context.Context, month time.Time) (int64, error) {
+	const query = `
+		INSERT INTO email_queue (user_id, kind, locale, address, data, dedup_key)
+		SELECT p.user_id,
+		       'monthly_summary',
+		       p.locale,
+		       p.address,
+		       jsonb_build_object(
+		               'month', to_char($1::date, 'YYYY-MM'),
+		               'total', sum(sh.amount),
+		               'items', jsonb_agg(jsonb_build_object('service_name', s.service_name, 'price', sh.amount)
+		                                  ORDER BY s.service_name)),
+		       'monthly_summary:' || p.user_id || ':' || to_char($1::date, 'YYYY-MM')
+		FROM subscriptions s
+		         CROSS JOIN LATERAL subscription_shares(
+		        s.id, s.user_id, s.price - subscription_discount(s.id, s.price, $1::date), $1::date) sh
+		         JOIN notification_preferences p ON p.user_id = sh.user_id
+		WHERE p.channel = 'email'
+		  AND p.monthly_summaries
+		  AND s.deleted_at IS NULL
+		  AND s.start_date <= $1::date
+		  AND (s.end_date IS NULL OR s.end_date > $1::date)
+		  AND (s.trial_end_date IS NULL OR s.trial_end_date <= $1::date)
+		  AND NOT EXISTS (SELECT 1
+		                  FROM subscription_pauses sp
+		                  WHERE sp.subscription_id = s.id
+		                    AND sp.start_month <= $1::date
+		                    AND (sp.end_month IS NULL OR sp.end_month > $1::date))
+		GROUP BY p.user_id, p.locale, p.address
+		ON CONFLICT (dedup_key) DO NOTHING;`
+
+	tag, err := r.postgresDB.Exec(ctx, query, month)
+	if err != nil {
+		return 0, err
+	}
+
+	if tag.RowsAffected() > 0 {
+		logger.Info(fmt.Sprintf("%d monthly summaries for %s queued", tag.RowsAffected(), month.Format("2006-01")))
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Claim leases due emails so that concurrent workers do not pick them up until the lease expires.
+func (r *EmailRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*model.Email, error) {
+	var emails []*model.Email
+	const query = `
+		UPDATE email_queue
+		SET next_attempt_at = now() + $2::interval
+		WHERE id IN (SELECT id
+		             FROM email_queue
+		             WHERE status = 'pending'
+		               AND next_attempt_at <= now()
+		             ORDER BY id
+		             LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING id, user_id, kind, locale, address, data, attempts;`
+
+	rows, err := r.postgresDB.Query(ctx, query, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		email := new(model.Email)
+		if err := rows.Scan(
+			&email.ID,
+			&email.UserId,
+			&email.Kind,
+			&email.Locale,
+			&email.Address,
+			&email.Data,
+			&email.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+func (r *EmailRepository) MarkSent(ctx context.Context, emailId int64) error {
+	const query = `
+		UPDATE email_queue
+		SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = now()
+		WHERE id = $1;`
+
+	if _, err := r.postgresDB.Exec(ctx, query, emailId); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("email with id %d sent", emailId))
+	return nil
+}
+
+// MarkFailed schedules the next attempt, or gives up on the email when nextAttemptAt is nil.
+func (r *EmailRepository) MarkFailed(ctx context.Context, emailId int64, lastError string, nextAttemptAt *time.Time) error {
+	const query = `
+		UPDATE email_queue
+		SET status = CASE WHEN $3::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+		    attempts = attempts + 1,
+		    last_error = $2,
+		    next_attempt_at = coalesce($3, next_attempt_at)
+		WHERE id = $1;`
+
+	if _, err := r.postgresDB.Exec(ctx, query, emailId, lastError, nextAttemptAt); err != nil {
+		return err
+	}
+
+	if nextAttemptAt == nil {
+		logger.Info(fmt.Sprintf("email with id %d dead-lettered: %s", emailId, lastError))
+	}
+	return nil
+}
+
+// enqueueCreatedEmail queues the new subscription email within the mutation transaction when the owner receives emails.
+func enqueueCreatedEmail(ctx context.Context, tx pgx.Tx, subscription []byte) error {
+	const query = `
+		INSERT INTO email_queue (user_id, kind, locale, address, data)
+		SELECT user_id, 'subscription_created', locale, address, jsonb_build_object('subscription', $1::jsonb)
+		FROM notification_preferences
+		WHERE user_id = $1::jsonb ->> 'user_id'
+		  AND channel = 'email';`
+
+	_, err := tx.Exec(ctx, query, subscription)
+	return err
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// noMemberOverlapConstraint rejects memberships of a user in a subscription with intersecting periods.
+const noMemberOverlapConstraint = "subscription_members_no_overlap"
+
+// AddMember shares the live subscription with the member from its start month. The explicit shares of the members
+// sharing it from that month on must not exceed 100 percent.
+func (r *SubscriptionRepository) AddMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error {
+	const (
+		sharesQuery = `
+			SELECT coalesce(sum(share), 0)
+			FROM subscription_members
+			WHERE subscription_id = $1
+			  AND (end_month IS NULL OR end_month > to_date($2, 'MM-YYYY'));`
+		insertQuery = `
+			INSERT INTO subscription_members (subscription_id, user_id, share, start_month)
+			VALUES ($1, $2, $3, to_date($4, 'MM-YYYY'))
+			RETURNING id, created_at;`
+	)
+
+	member.SubscriptionId = subscription.ID
+	if err := r.adjust(ctx, subscription.ID, model.ActionAddMember, "member", member, func(tx pgx.Tx) error {
+		if member.Share != nil {
+			var shares int64
+			if err := tx.QueryRow(ctx, sharesQuery, member.SubscriptionId, member.StartDate).Scan(&shares); err != nil {
+				return err
+			}
+
+			if shares+int64(*member.Share) > 100 {
+				return fmt.Errorf("%w: shares exceed 100 percent", err_msg.InvalidMember)
+			}
+		}
+
+		if err := tx.QueryRow(
+			ctx,
+			insertQuery,
+			member.SubscriptionId,
+			member.UserId,
+			member.Share,
+			member.StartDate,
+		).Scan(&member.ID, &member.CreatedAt); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.ConstraintName == noMemberOverlapConstraint {
+				return fmt.Errorf("%w: user %s already shares the subscription then", err_msg.InvalidMember, member.UserId)
+			}
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("member with id %d added to subscription with id %d", member.ID, subscription.ID))
+	return nil
+}
+
+// RemoveMember stops sharing the subscription with the member from month member.EndDate, or from its start month
+// if that is later. The membership is kept, so totals as of an earlier time still split the cost with it.
+func (r *SubscriptionRepository) RemoveMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error {
+	const query = `
+		UPDATE subscription_members
+		SET end_month  = greatest(start_month, to_date($3, 'MM-YYYY')),
+		    removed_at = now()
+		WHERE id = $1
+		  AND subscription_id = $2
+		  AND removed_at IS NULL
+		RETURNING subscription_id, user_id, share, to_char(start_month, 'MM-YYYY'), to_char(end_month, 'MM-YYYY'),
+		          created_at;`
+
+	if err := r.adjust(ctx, subscription.ID, model.ActionRemoveMember, "member", member, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, member.ID, subscription.ID, member.EndDate).Scan(
+			&member.SubscriptionId,
+			&member.UserId,
+			&member.Share,
+			&member.StartDate,
+			&member.EndDate,
+			&member.CreatedAt,
+		); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return err_msg.NoRowsAffected
+			}
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("member with id %d removed from subscription with id %d", member.ID, subscription.ID))
+	return nil
+}
+
+// Members lists the current, future and past members of the subscription.
+func (r *SubscriptionRepository) Members(ctx context.Context, subscriptionId int64) ([]*model.Member, error) {
+	var members []*model.Member
+	const query = `
+		SELECT id,
+		       subscription_id,
+		       user_id,
+		       share,
+		       to_char(start_month, 'MM-YYYY'),
+		       coalesce(to_char(end_month, 'MM-YYYY'), ''),
+		       created_at
+		FROM subscription_members
+		WHERE subscription_id = $1
+		ORDER BY start_month, id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		member := new(model.Member)
+		if err := rows.Scan(
+			&member.ID,
+			&member.SubscriptionId,
+			&member.UserId,
+			&member.Share,
+			&member.StartDate,
+			&member.EndDate,
+			&member.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d members of subscription with id %d listed", len(members), subscriptionId))
+	return members, nil
+}
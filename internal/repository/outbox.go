@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+// outboxLock is the advisory lock key that serializes the claims of the relays, which preserves event order.
+const outboxLock = "outbox_relay"
+
+// outboxChannel is notified with the id of every event appended to the outbox, once its transaction commits.
+const outboxChannel = "outbox_events"
+
+type OutboxRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewOutboxRepository(postgresDB *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+// Claim leases the next unpublished events in outbox order so that concurrent relays do not publish them until the
+// lease expires. An event is not claimed while an earlier event of its ordering key is leased to another relay, which
+// keeps the events of a subscription or a budget in order. Claims are serialized by an advisory lock held only for the claim.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*events.Envelope, error) {
+	var envelopes []*events.Envelope
+	const (
+		lockQuery = `
+			SELECT pg_advisory_xact_lock(hashtext($1));`
+		claimQuery = `
+			UPDATE outbox
+			SET claimed_until = now() + $2::interval
+			WHERE id IN (SELECT o.id
+			             FROM outbox o
+			             WHERE o.published_at IS NULL
+			               AND (o.claimed_until IS NULL OR o.claimed_until <= now())
+			               AND NOT EXISTS (SELECT 1
+			                               FROM outbox e
+			                               WHERE e.ordering_key = o.ordering_key
+			                                 AND e.id < o.id
+			                                 AND e.published_at IS NULL
+			                                 AND e.claimed_until > now())
+			             ORDER BY o.id
+			             LIMIT $1)
+			RETURNING id, type, ordering_key, subscription_id, user_id, service_name, payload, occurred_at;`
+	)
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, lockQuery, outboxLock); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, claimQuery, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		envelope := new(events.Envelope)
+		if err := rows.Scan(
+			&envelope.ID,
+			&envelope.Type,
+			&envelope.Key,
+			&envelope.SubscriptionId,
+			&envelope.UserId,
+			&envelope.ServiceName,
+			&envelope.Payload,
+			&envelope.OccurredAt,
+		); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the subquery.
+	slices.SortFunc(envelopes, func(a, b *events.Envelope) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return envelopes, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, eventId int64) error {
+	const query = `
+		UPDATE outbox
+		SET published_at = now(), claimed_until = NULL
+		WHERE id = $1;`
+
+	_, err := r.postgresDB.Exec(ctx, query, eventId)
+	return err
+}
+
+// Release gives up the lease of claimed events that were not published, so that the next claim picks them up again.
+func (r *OutboxRepository) Release(ctx context.Context, eventIds []int64) error {
+	const query = `
+		UPDATE outbox
+		SET claimed_until = NULL
+		WHERE id = ANY ($1)
+		  AND published_at IS NULL;`
+
+	_, err := r.postgresDB.Exec(ctx, query, eventIds)
+	return err
+}
+
+func (r *OutboxRepository) Read(ctx context.Context, eventId int64) (*events.Envelope, error) {
+	envelope := new(events.Envelope)
+	const query = `
+		SELECT id, type, ordering_key, subscription_id, user_id, service_name, payload, occurred_at
+		FROM outbox
+		WHERE id = $1;`
+
+	if err := r.postgresDB.QueryRow(ctx, query, eventId).Scan(
+		&envelope.ID,
+		&envelope.Type,
+		&envelope.Key,
+		&envelope.SubscriptionId,
+		&envelope.UserId,
+		&envelope.ServiceName,
+		&envelope.Payload,
+		&envelope.OccurredAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// After returns the logged events following afterId that match the filter, oldest first.
+func (r *OutboxRepository) After(ctx context.Context, afterId int64, filter *events.Filter, limit int) ([]*events.Envelope, error) {
+	var envelopes []*events.Envelope
+	const query = `
+		SELECT id, type, ordering_key, subscription_id, user_id, service_name, payload, occurred_at
+		FROM outbox
+		WHERE id > $1
+		  AND subscription_id IS NOT NULL
+		  AND ($2 = '' OR user_id = $2)
+		  AND ($3 = '' OR service_name = $3)
+		ORDER BY id
+		LIMIT $4;`
+
+	rows, err := r.postgresDB.Query(ctx, query, afterId, filter.UserId, filter.ServiceName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		envelope := new(events.Envelope)
+		if err := rows.Scan(
+			&envelope.ID,
+			&envelope.Type,
+			&envelope.Key,
+			&envelope.SubscriptionId,
+			&envelope.UserId,
+			&envelope.ServiceName,
+			&envelope.Payload,
+			&envelope.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return envelopes, nil
+}
+
+// Listen blocks on the outbox notification channel and passes the id of every committed event to handle.
+func (r *OutboxRepository) Listen(ctx context.Context, handle func(eventId int64)) error {
+	conn, err := r.postgresDB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxChannel); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("listening on %s", outboxChannel))
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		eventId, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+
+		handle(eventId)
+	}
+}
+
+func appendOutbox(ctx context.Context, tx pgx.Tx, event events.Event) error {
+	const query = `
+		WITH event AS (
+		    INSERT INTO outbox (subscription_id, ordering_key, user_id, service_name, type, payload)
+		    VALUES (nullif($1, 0), $2, $3, $4, $5, $6)
+		    RETURNING id
+		)
+		SELECT pg_notify($7, id::text)
+		FROM event;`
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		query,
+		event.SubscriptionId(),
+		events.Key(event),
+		event.UserId(),
+		event.ServiceName(),
+		event.Type(),
+		payload,
+		outboxChannel,
+	)
+	return err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type PreferenceRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewPreferenceRepository(postgresDB *pgxpool.Pool) *PreferenceRepository {
+	return &PreferenceRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+// Read returns the stored preferences of the user, or the defaults when none were saved.
+func (r *PreferenceRepository) Read(ctx context.Context, userId string) (*model.Preference, error) {
+	preference := &model.Preference{
+		UserId:           userId,
+		Channel:          model.ChannelLog,
+		RenewalReminders: true,
+		EndReminders:     true,
+		MonthlySummaries: true,
+		Locale:           model.DefaultLocale,
+	}
+	const query = `
+		SELECT channel, address, renewal_reminders, end_reminders, monthly_summaries, locale
+		FROM notification_preferences
+		WHERE user_id = $1;`
+
+	if err := r.postgresDB.QueryRow(ctx, query, userId).Scan(
+		&preference.Channel,
+		&preference.Address,
+		&preference.RenewalReminders,
+		&preference.EndReminders,
+		&preference.MonthlySummaries,
+		&preference.Locale,
+	); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (r *PreferenceRepository) Save(ctx context.Context, preference *model.Preference) error {
+	const query = `
+		INSERT INTO notification_preferences (user_id, channel, address, renewal_reminders, end_reminders,
+		                                      monthly_summaries, locale)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE
+		    SET channel           = excluded.channel,
+		        address           = excluded.address,
+		        renewal_reminders = excluded.renewal_reminders,
+		        end_reminders     = excluded.end_reminders,
+		        monthly_summaries = excluded.monthly_summaries,
+		        locale            = excluded.locale;`
+
+	if _, err := r.postgresDB.Exec(
+		ctx,
+		query,
+		preference.UserId,
+		preference.Channel,
+		preference.Address,
+		preference.RenewalReminders,
+		preference.EndReminders,
+		preference.MonthlySummaries,
+		preference.Locale,
+	); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("notification preferences of user %s saved", preference.UserId))
+	return nil
+}
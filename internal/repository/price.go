@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// SchedulePrice projects the live subscription at the price from its start month in the spending forecast.
+func (r *SubscriptionRepository) SchedulePrice(ctx context.Context, subscription *model.Subscription, price *model.ScheduledPrice) error {
+	const query = `
+		INSERT INTO subscription_price_changes (subscription_id, price, start_month)
+		VALUES ($1, $2, to_date($3, 'MM-YYYY'))
+		RETURNING id, created_at;`
+
+	price.SubscriptionId = subscription.ID
+	if err := r.adjust(ctx, subscription.ID, model.ActionSchedulePrice, "scheduled_price", price, func(tx pgx.Tx) error {
+		return tx.QueryRow(
+			ctx,
+			query,
+			price.SubscriptionId,
+			price.Price,
+			price.StartDate,
+		).Scan(&price.ID, &price.CreatedAt)
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("price with id %d scheduled for subscription with id %d", price.ID, subscription.ID))
+	return nil
+}
+
+// RemovePrice stops applying the scheduled price of the subscription. The price is kept with the time it was removed.
+func (r *SubscriptionRepository) RemovePrice(ctx context.Context, subscription *model.Subscription, priceId int64) error {
+	const query = `
+		UPDATE subscription_price_changes
+		SET removed_at = now()
+		WHERE id = $1
+		  AND subscription_id = $2
+		  AND removed_at IS NULL
+		RETURNING id, subscription_id, price, to_char(start_month, 'MM-YYYY'), created_at;`
+
+	price := new(model.ScheduledPrice)
+	if err := r.adjust(ctx, subscription.ID, model.ActionRemovePrice, "scheduled_price", price, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, priceId, subscription.ID).Scan(
+			&price.ID,
+			&price.SubscriptionId,
+			&price.Price,
+			&price.StartDate,
+			&price.CreatedAt,
+		); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return err_msg.NoRowsAffected
+			}
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("price with id %d removed from subscription with id %d", priceId, subscription.ID))
+	return nil
+}
+
+// ScheduledPrices lists the prices scheduled for the subscription.
+func (r *SubscriptionRepository) ScheduledPrices(ctx context.Context, subscriptionId int64) ([]*model.ScheduledPrice, error) {
+	var prices []*model.ScheduledPrice
+	const query = `
+		SELECT id, subscription_id, price, to_char(start_month, 'MM-YYYY'), created_at
+		FROM subscription_price_changes
+		WHERE subscription_id = $1
+		  AND removed_at IS NULL
+		ORDER BY start_month, id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		price := new(model.ScheduledPrice)
+		if err := rows.Scan(
+			&price.ID,
+			&price.SubscriptionId,
+			&price.Price,
+			&price.StartDate,
+			&price.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d prices of subscription with id %d listed", len(prices), subscriptionId))
+	return prices, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type ReminderRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewReminderRepository(postgresDB *pgxpool.Pool) *ReminderRepository {
+	return &ReminderRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+// Due returns the reminders not sent yet for renewals (the first day of the next month) within renewalWindow
+// and for end dates within endWindow, honoring the user's preferences.
+func (r *ReminderRepository) Due(ctx context.Context, now time.Time, renewalWindow, endWindow time.Duration) ([]*model.Reminder, error) {
+	var reminders []*model.Reminder
+	const query = `
+		SELECT s.id, s.user_id, s.service_name, s.price, d.kind, d.due_date,
+		       coalesce(p.channel, 'log'), coalesce(p.address, ''), coalesce(p.locale, 'en')
+		FROM subscriptions s
+		         LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+		         CROSS JOIN LATERAL (VALUES ('renewal', (date_trunc('month', $1::timestamptz) + interval '1 month')::date),
+		                                    ('end', s.end_date)) AS d(kind, due_date)
+		WHERE s.deleted_at IS NULL
+		  AND d.due_date IS NOT NULL
+		  AND d.due_date >= $1::date
+		  AND coalesce(p.channel, 'log') <> 'none'
+		  AND ((d.kind = 'renewal'
+		            AND d.due_date < $1::timestamptz + $2::interval
+		            AND s.start_date < d.due_date
+		            AND (s.end_date IS NULL OR s.end_date > d.due_date)
+		            AND NOT EXISTS (SELECT 1
+		                            FROM subscription_pauses sp
+		                            WHERE sp.subscription_id = s.id
+		                              AND sp.start_month <= d.due_date
+		                              AND (sp.end_month IS NULL OR sp.end_month > d.due_date))
+		            AND coalesce(p.renewal_reminders, true))
+		    OR (d.kind = 'end'
+		            AND d.due_date < $1::timestamptz + $3::interval
+		            AND coalesce(p.end_reminders, true)))
+		  AND NOT EXISTS (SELECT 1
+		                  FROM reminders x
+		                  WHERE x.subscription_id = s.id
+		                    AND x.kind = d.kind
+		                    AND x.due_date = d.due_date)
+		ORDER BY s.id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, now, renewalWindow, endWindow)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reminder := new(model.Reminder)
+		if err := rows.Scan(
+			&reminder.SubscriptionId,
+			&reminder.UserId,
+			&reminder.ServiceName,
+			&reminder.Price,
+			&reminder.Kind,
+			&reminder.DueDate,
+			&reminder.Channel,
+			&reminder.Address,
+			&reminder.Locale,
+		); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Claim records the reminder as sent and reports false when it already was, which deduplicates sends.
+func (r *ReminderRepository) Claim(ctx context.Context, reminder *model.Reminder) (bool, error) {
+	const query = `
+		INSERT INTO reminders (subscription_id, kind, due_date, channel)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING;`
+
+	tag, err := r.postgresDB.Exec(ctx, query, reminder.SubscriptionId, reminder.Kind, reminder.DueDate, reminder.Channel)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// Release forgets a claimed reminder whose sending failed, so that the next run retries it.
+func (r *ReminderRepository) Release(ctx context.Context, reminder *model.Reminder) error {
+	const query = `
+		DELETE FROM reminders
+		WHERE subscription_id = $1
+		  AND kind = $2
+		  AND due_date = $3;`
+
+	if _, err := r.postgresDB.Exec(ctx, query, reminder.SubscriptionId, reminder.Kind, reminder.DueDate); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("%s reminder of subscription with id %d released", reminder.Kind, reminder.SubscriptionId))
+	return nil
+}
@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// activeMonths selects the months from $1 to $2 with the subscriptions billed in each of them, that is active and
+// neither in trial nor paused, priced with the version of the subscription known at the end of the month when its
+// history has one, less the discounts of the month, once for the owner and every member with their shares.
+const activeMonths = `
+		WITH months AS (
+		    SELECT generate_series($1::date, $2::date, interval '1 month')::date AS month
+		),
+		     active AS (
+		         SELECT m.month, s.id, sh.user_id, s.service_name, sh.amount AS price
+		         FROM months m
+		                  JOIN subscriptions s
+		                       ON s.deleted_at IS NULL
+		                           AND s.start_date <= m.month
+		                           AND (s.end_date IS NULL OR s.end_date > m.month)
+		                           AND (s.trial_end_date IS NULL OR s.trial_end_date <= m.month)
+		                           AND NOT EXISTS (SELECT 1
+		                                           FROM subscription_pauses sp
+		                                           WHERE sp.subscription_id = s.id
+		                                             AND sp.start_month <= m.month
+		                                             AND (sp.end_month IS NULL OR sp.end_month > m.month))
+		                  LEFT JOIN LATERAL (
+		             SELECT price
+		             FROM subscription_versions
+		             WHERE id = s.id
+		               AND valid_from < m.month + interval '1 month'
+		             ORDER BY valid_from DESC
+		             LIMIT 1
+		             ) v ON true
+		                  CROSS JOIN LATERAL (SELECT coalesce(v.price, s.price) AS price) p
+		                  CROSS JOIN LATERAL subscription_shares(
+		             s.id, s.user_id, p.price - subscription_discount(s.id, p.price, m.month), m.month) sh
+		     )`
+
+type ReportRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewReportRepository(postgresDB *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+// Forecast projects the monthly spend of the user from the first day of month from: the shares of the user in the
+// subscriptions billed each month, that is neither ended, in trial nor paused, at the price scheduled for the month
+// or else the price of the subscription, less the discounts of the month.
+func (r *ReportRepository) Forecast(ctx context.Context, userId string, from time.Time, months int) ([]*model.MonthlyCost, error) {
+	var costs []*model.MonthlyCost
+	const query = `
+		WITH months AS (
+		    SELECT generate_series($2::date, $2::date + ($3 - 1) * interval '1 month', interval '1 month')::date AS month
+		),
+		     billed AS (
+		         SELECT m.month, sh.amount
+		         FROM months m
+		                  JOIN subscriptions s
+		                       ON s.deleted_at IS NULL
+		                           AND date_trunc('month', greatest(s.start_date, s.trial_end_date)) <= m.month
+		                           AND (s.end_date IS NULL OR date_trunc('month', s.end_date) > m.month)
+		                           AND NOT EXISTS (SELECT 1
+		                                           FROM subscription_pauses sp
+		                                           WHERE sp.subscription_id = s.id
+		                                             AND sp.start_month <= m.month
+		                                             AND (sp.end_month IS NULL OR sp.end_month > m.month))
+		                  LEFT JOIN LATERAL (
+		             SELECT price
+		             FROM subscription_price_changes
+		             WHERE subscription_id = s.id
+		               AND removed_at IS NULL
+		               AND start_month <= m.month
+		             ORDER BY start_month DESC, id DESC
+		             LIMIT 1
+		             ) sc ON true
+		                  CROSS JOIN LATERAL (SELECT coalesce(sc.price, s.price) AS price) p
+		                  CROSS JOIN LATERAL subscription_shares(
+		             s.id, s.user_id, p.price - subscription_discount(s.id, p.price, m.month), m.month) sh
+		         WHERE sh.user_id = $1
+		     )
+		SELECT to_char(m.month, 'MM-YYYY'), coalesce(sum(b.amount), 0)
+		FROM months m
+		         LEFT JOIN billed b ON b.month = m.month
+		GROUP BY m.month
+		ORDER BY m.month;`
+
+	rows, err := r.postgresDB.Query(ctx, query, userId, from, months)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cost := new(model.MonthlyCost)
+		if err := rows.Scan(&cost.Month, &cost.Total); err != nil {
+			return nil, err
+		}
+		costs = append(costs, cost)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("forecast of %d months for user %s", months, userId))
+	return costs, nil
+}
+
+// MRR returns the recurring revenue of every month from from to to with its movements per user.
+func (r *ReportRepository) MRR(ctx context.Context, from, to time.Time) ([]*model.MRRMonth, error) {
+	var result []*model.MRRMonth
+	const query = activeMonths + `,
+		     user_mrr AS (
+		         SELECT month, user_id, sum(price) AS mrr
+		         FROM active
+		         GROUP BY month, user_id
+		     ),
+		     users AS (
+		         SELECT month, user_id
+		         FROM user_mrr
+		         UNION
+		         SELECT (month + interval '1 month')::date, user_id
+		         FROM user_mrr
+		     ),
+		     movements AS (
+		         SELECT u.month, coalesce(c.mrr, 0) AS current, coalesce(p.mrr, 0) AS previous
+		         FROM users u
+		                  LEFT JOIN user_mrr c ON c.month = u.month AND c.user_id = u.user_id
+		                  LEFT JOIN user_mrr p ON p.month = (u.month - interval '1 month')::date AND p.user_id = u.user_id
+		     )
+		SELECT to_char(m.month, 'MM-YYYY'),
+		       count(mv.month) FILTER (WHERE mv.current > 0),
+		       coalesce(sum(mv.current), 0),
+		       coalesce(sum(mv.current) FILTER (WHERE mv.previous = 0), 0),
+		       coalesce(sum(mv.current - mv.previous) FILTER (WHERE mv.previous > 0 AND mv.current > mv.previous), 0),
+		       coalesce(sum(mv.previous - mv.current) FILTER (WHERE mv.current > 0 AND mv.current < mv.previous), 0),
+		       coalesce(sum(mv.previous) FILTER (WHERE mv.current = 0), 0)
+		FROM months m
+		         LEFT JOIN movements mv ON mv.month = m.month
+		WHERE m.month > $1::date
+		GROUP BY m.month
+		ORDER BY m.month;`
+
+	// the range starts a month earlier, so that the movements of the first month have a previous month
+	rows, err := r.postgresDB.Query(ctx, query, from.AddDate(0, -1, 0), to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		month := new(model.MRRMonth)
+		if err := rows.Scan(
+			&month.Month,
+			&month.Subscribers,
+			&month.MRR,
+			&month.New,
+			&month.Expansion,
+			&month.Contraction,
+			&month.Churned,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, month)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Subscribers counts the users with an active subscription to each service in every month from from to to.
+func (r *ReportRepository) Subscribers(ctx context.Context, from, to time.Time) ([]*model.ServiceSubscribers, error) {
+	var result []*model.ServiceSubscribers
+	const query = activeMonths + `
+		SELECT to_char(month, 'MM-YYYY'), service_name, count(DISTINCT user_id)
+		FROM active
+		GROUP BY month, service_name
+		ORDER BY month, service_name;`
+
+	rows, err := r.postgresDB.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscribers := new(model.ServiceSubscribers)
+		if err := rows.Scan(&subscribers.Month, &subscribers.ServiceName, &subscribers.Subscribers); err != nil {
+			return nil, err
+		}
+		result = append(result, subscribers)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Retention follows the cohorts of users whose first subscription started from from to to until the month to.
+func (r *ReportRepository) Retention(ctx context.Context, from, to time.Time) ([]*model.Cohort, error) {
+	var (
+		result []*model.Cohort
+		cohort *model.Cohort
+	)
+	const query = `
+		WITH cohorts AS (
+		    SELECT user_id, date_trunc('month', min(start_date))::date AS cohort
+		    FROM subscriptions
+		    WHERE deleted_at IS NULL
+		    GROUP BY user_id
+		    HAVING date_trunc('month', min(start_date)) BETWEEN $1::date AND $2::date
+		),
+		     months AS (
+		         SELECT generate_series($1::date, $2::date, interval '1 month')::date AS month
+		     )
+		SELECT to_char(c.cohort, 'MM-YYYY'), count(*), count(a.user_id)
+		FROM cohorts c
+		         JOIN months m ON m.month >= c.cohort
+		         LEFT JOIN LATERAL (
+		    SELECT s.user_id
+		    FROM subscriptions s
+		    WHERE s.user_id = c.user_id
+		      AND s.deleted_at IS NULL
+		      AND s.start_date <= m.month
+		      AND (s.end_date IS NULL OR s.end_date > m.month)
+		      AND NOT EXISTS (SELECT 1
+		                      FROM subscription_pauses sp
+		                      WHERE sp.subscription_id = s.id
+		                        AND sp.start_month <= m.month
+		                        AND (sp.end_month IS NULL OR sp.end_month > m.month))
+		    LIMIT 1
+		    ) a ON true
+		GROUP BY c.cohort, m.month
+		ORDER BY c.cohort, m.month;`
+
+	rows, err := r.postgresDB.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			month          string
+			size, retained int64
+		)
+		if err := rows.Scan(&month, &size, &retained); err != nil {
+			return nil, err
+		}
+
+		if cohort == nil || cohort.Month != month {
+			cohort = &model.Cohort{Month: month, Size: size}
+			result = append(result, cohort)
+		}
+		cohort.Retained = append(cohort.Retained, retained)
+		cohort.Retention = append(cohort.Retention, math.Round(float64(retained)*10000/float64(size))/100)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Overlaps lists the pairs of live subscriptions of the user, or of every user when userId is empty, to the same
+// service whose periods intersect, whether or not they were written under the no-overlap rule.
+func (r *ReportRepository) Overlaps(ctx context.Context, userId string) ([]*model.Overlap, error) {
+	var overlaps []*model.Overlap
+	const query = `
+		SELECT a.user_id,
+		       a.service_name,
+		       a.id,
+		       b.id,
+		       to_char(greatest(a.start_date, b.start_date), 'MM-YYYY'),
+		       coalesce(to_char(least(a.end_date, b.end_date), 'MM-YYYY'), '')
+		FROM subscriptions a
+		         JOIN subscriptions b
+		              ON b.user_id = a.user_id
+		                  AND b.service_name = a.service_name
+		                  AND b.id > a.id
+		                  AND b.deleted_at IS NULL
+		WHERE a.deleted_at IS NULL
+		  AND ($1 = '' OR a.user_id = $1)
+		  AND daterange(a.start_date, a.end_date) && daterange(b.start_date, b.end_date)
+		ORDER BY a.user_id, a.service_name, a.id, b.id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		overlap := new(model.Overlap)
+		if err := rows.Scan(
+			&overlap.UserId,
+			&overlap.ServiceName,
+			&overlap.SubscriptionId,
+			&overlap.OverlappingId,
+			&overlap.StartDate,
+			&overlap.EndDate,
+		); err != nil {
+			return nil, err
+		}
+		overlaps = append(overlaps, overlap)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d overlapping subscriptions listed", len(overlaps)))
+	return overlaps, nil
+}
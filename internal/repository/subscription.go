@@ -2,32 +2,67 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/events"
 	"github.com/oatsmoke/20250905/internal/lib/err_msg"
 	"github.com/oatsmoke/20250905/internal/lib/logger"
 	"github.com/oatsmoke/20250905/internal/model"
 )
 
+// snapshotAsOf selects the current subscriptions, or their versions valid at $1 when it is set.
+const snapshotAsOf = `
+		WITH snapshot AS (
+		    SELECT id, service_name, price, user_id, start_date, end_date, deleted_at, status, trial_end_date
+		    FROM subscriptions
+		    WHERE $1::timestamptz IS NULL
+		    UNION ALL
+		    SELECT id, service_name, price, user_id, start_date, end_date, deleted_at, status, trial_end_date
+		    FROM subscription_versions
+		    WHERE $1::timestamptz IS NOT NULL
+		      AND valid_from <= $1
+		      AND (valid_to IS NULL OR valid_to > $1)
+		)`
+
+// noOverlapConstraint rejects live subscriptions of a user to a service with intersecting periods among the rows
+// written with no_overlap set.
+const noOverlapConstraint = "subscriptions_no_overlap"
+
 type SubscriptionRepository struct {
 	postgresDB *pgxpool.Pool
+	noOverlap  bool
 }
 
-func New(postgresDB *pgxpool.Pool) *SubscriptionRepository {
+// New returns the subscription repository. With noOverlap the subscriptions it writes must not overlap each other.
+func New(postgresDB *pgxpool.Pool, noOverlap bool) *SubscriptionRepository {
 	return &SubscriptionRepository{
 		postgresDB: postgresDB,
+		noOverlap:  noOverlap,
 	}
 }
 
 func (r *SubscriptionRepository) Create(ctx context.Context, subscription *model.Subscription) error {
-	var id int64
+	var (
+		id    int64
+		after []byte
+	)
 	const query = `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id;`
+		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date, no_overlap, status, trial_end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, to_jsonb(subscriptions.*);`
 
-	if err := r.postgresDB.QueryRow(
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(
 		ctx,
 		query,
 		subscription.ServiceName,
@@ -35,33 +70,51 @@ func (r *SubscriptionRepository) Create(ctx context.Context, subscription *model
 		subscription.UserId,
 		subscription.StartDate,
 		subscription.EndDate,
-	).Scan(&id); err != nil {
-		return err
+		r.noOverlap,
+		subscription.Status,
+		subscription.TrialEndDate,
+	).Scan(&id, &after); err != nil {
+		return r.overlap(ctx, err, subscription)
 	}
 
 	if id == 0 {
 		return err_msg.NoRowsAffected
 	}
 
+	if err := record(ctx, tx, id, model.ActionCreate, nil, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
 	logger.Info(fmt.Sprintf("subscription with id %d created", id))
 	return nil
 }
 
-func (r *SubscriptionRepository) Read(ctx context.Context, subscriptionId int64) (*model.Subscription, error) {
+func (r *SubscriptionRepository) Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.Subscription, error) {
 	subscription := new(model.Subscription)
-	const query = `
-		SELECT id, service_name, price, user_id, start_date, end_date
-		FROM subscriptions
-		WHERE id = $1;`
+	const query = snapshotAsOf + `
+		SELECT id, service_name, price, user_id, start_date, end_date, deleted_at, status, trial_end_date
+		FROM snapshot
+		WHERE id = $2
+		  AND ($3 OR deleted_at IS NULL);`
 
-	if err := r.postgresDB.QueryRow(ctx, query, subscriptionId).Scan(
+	if err := r.postgresDB.QueryRow(ctx, query, filter.AsOf, subscriptionId, filter.IncludeDeleted).Scan(
 		&subscription.ID,
 		&subscription.ServiceName,
 		&subscription.Price,
 		&subscription.UserId,
 		&subscription.StartDate,
 		&subscription.EndDate,
+		&subscription.DeletedAt,
+		&subscription.Status,
+		&subscription.TrialEndDate,
 	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err_msg.NoRowsAffected
+		}
 		return nil, err
 	}
 
@@ -70,12 +123,33 @@ func (r *SubscriptionRepository) Read(ctx context.Context, subscriptionId int64)
 }
 
 func (r *SubscriptionRepository) Update(ctx context.Context, subscription *model.Subscription) error {
+	var after []byte
 	const query = `
 		UPDATE subscriptions
-		SET service_name = $2, price = $3, user_id = $4, start_date = $5, end_date = $6
-		WHERE id = $1;`
+		SET service_name   = $2,
+		    price          = $3,
+		    user_id        = $4,
+		    start_date     = $5,
+		    end_date       = $6,
+		    no_overlap     = $7,
+		    trial_end_date = $8,
+		    status         = CASE WHEN status IN ('active', 'trial') THEN $9 ELSE status END
+		WHERE id = $1
+		  AND deleted_at IS NULL
+		RETURNING to_jsonb(subscriptions.*);`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := snapshot(ctx, tx, subscription.ID)
+	if err != nil {
+		return err
+	}
 
-	tag, err := r.postgresDB.Exec(
+	if err := tx.QueryRow(
 		ctx,
 		query,
 		subscription.ID,
@@ -84,13 +158,22 @@ func (r *SubscriptionRepository) Update(ctx context.Context, subscription *model
 		subscription.UserId,
 		subscription.StartDate,
 		subscription.EndDate,
-	)
-	if err != nil {
+		r.noOverlap,
+		subscription.TrialEndDate,
+		subscription.Status,
+	).Scan(&after); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err_msg.NoRowsAffected
+		}
+		return r.overlap(ctx, err, subscription)
+	}
+
+	if err := record(ctx, tx, subscription.ID, model.ActionUpdate, before, after); err != nil {
 		return err
 	}
 
-	if tag.RowsAffected() == 0 {
-		return err_msg.NoRowsAffected
+	if err := tx.Commit(ctx); err != nil {
+		return err
 	}
 
 	logger.Info(fmt.Sprintf("subscription with id %d updated", subscription.ID))
@@ -98,30 +181,296 @@ func (r *SubscriptionRepository) Update(ctx context.Context, subscription *model
 }
 
 func (r *SubscriptionRepository) Delete(ctx context.Context, subscriptionId int64) error {
+	var after []byte
 	const query = `
-		DELETE FROM subscriptions
-		WHERE id = $1;`
+		UPDATE subscriptions
+		SET deleted_at = now()
+		WHERE id = $1
+		  AND deleted_at IS NULL
+		RETURNING to_jsonb(subscriptions.*);`
 
-	tag, err := r.postgresDB.Exec(ctx, query, subscriptionId)
+	tx, err := r.postgresDB.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	if tag.RowsAffected() == 0 {
-		return err_msg.NoRowsAffected
+	before, err := snapshot(ctx, tx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(ctx, query, subscriptionId).Scan(&after); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err_msg.NoRowsAffected
+		}
+		return err
+	}
+
+	if err := record(ctx, tx, subscriptionId, model.ActionDelete, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
 	}
 
 	logger.Info(fmt.Sprintf("subscription with id %d deleted", subscriptionId))
 	return nil
 }
 
-func (r *SubscriptionRepository) List(ctx context.Context) ([]*model.Subscription, error) {
-	var subscriptions []*model.Subscription
+func (r *SubscriptionRepository) Restore(ctx context.Context, subscriptionId int64) error {
+	var after []byte
+	const query = `
+		UPDATE subscriptions
+		SET deleted_at = NULL, no_overlap = $2
+		WHERE id = $1
+		  AND deleted_at IS NOT NULL
+		RETURNING to_jsonb(subscriptions.*);`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := snapshot(ctx, tx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(ctx, query, subscriptionId, r.noOverlap).Scan(&after); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err_msg.NoRowsAffected
+		}
+
+		subscription, readErr := r.Read(ctx, subscriptionId, &model.Filter{IncludeDeleted: true})
+		if readErr != nil {
+			return err
+		}
+		return r.overlap(ctx, err, subscription)
+	}
+
+	if err := record(ctx, tx, subscriptionId, model.ActionRestore, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("subscription with id %d restored", subscriptionId))
+	return nil
+}
+
+// Purge removes the subscriptions deleted before deletedBefore and returns them.
+func (r *SubscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]*model.Subscription, error) {
+	const query = `
+		DELETE FROM subscriptions
+		WHERE deleted_at < $1
+		RETURNING id, user_id, service_name, to_jsonb(subscriptions.*);`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	type purgedRow struct {
+		subscription *model.Subscription
+		before       []byte
+	}
+	var purgedRows []purgedRow
+	for rows.Next() {
+		row := purgedRow{subscription: new(model.Subscription)}
+		if err := rows.Scan(&row.subscription.ID, &row.subscription.UserId, &row.subscription.ServiceName, &row.before); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		purgedRows = append(purgedRows, row)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	purged := make([]*model.Subscription, 0, len(purgedRows))
+	for _, row := range purgedRows {
+		if err := audit(ctx, tx, row.subscription.ID, model.ActionPurge, row.before, nil); err != nil {
+			return nil, err
+		}
+		purged = append(purged, row.subscription)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d deleted subscriptions purged", len(purged)))
+	return purged, nil
+}
+
+// ConvertTrials makes the subscriptions whose trial has ended by now active, recording the conversion of each, and
+// returns them.
+func (r *SubscriptionRepository) ConvertTrials(ctx context.Context, now time.Time) ([]*model.Subscription, error) {
 	const query = `
-		SELECT id, service_name, price, user_id, start_date, end_date
-		FROM subscriptions;`
+		UPDATE subscriptions s
+		SET status = 'active'
+		FROM (SELECT id, to_jsonb(subscriptions.*) AS before
+		      FROM subscriptions
+		      WHERE status = 'trial'
+		        AND trial_end_date <= $1::date
+		        AND deleted_at IS NULL
+		      ORDER BY id
+		      FOR UPDATE) AS t
+		WHERE s.id = t.id
+		RETURNING s.id, s.user_id, s.service_name, t.before, to_jsonb(s.*);`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+
+	type convertedRow struct {
+		subscription  *model.Subscription
+		before, after []byte
+	}
+	var convertedRows []convertedRow
+	for rows.Next() {
+		row := convertedRow{subscription: new(model.Subscription)}
+		if err := rows.Scan(&row.subscription.ID, &row.subscription.UserId, &row.subscription.ServiceName, &row.before, &row.after); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		convertedRows = append(convertedRows, row)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	converted := make([]*model.Subscription, 0, len(convertedRows))
+	for _, row := range convertedRows {
+		if err := record(ctx, tx, row.subscription.ID, model.ActionConvert, row.before, row.after); err != nil {
+			return nil, err
+		}
+		converted = append(converted, row.subscription)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(converted) > 0 {
+		logger.Info(fmt.Sprintf("%d trials converted", len(converted)))
+	}
+	return converted, nil
+}
+
+// EndSubscriptions announces the subscriptions whose end date has passed by now with a subscription.ended event, once
+// per end date, including those that reached it without being updated, and returns them.
+func (r *SubscriptionRepository) EndSubscriptions(ctx context.Context, now time.Time) ([]*model.Subscription, error) {
+	const query = `
+		SELECT id, user_id, service_name
+		FROM subscriptions s
+		WHERE end_date <= $1::date
+		  AND deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1
+		                  FROM subscription_endings e
+		                  WHERE e.subscription_id = s.id
+		                    AND e.end_date = s.end_date)
+		ORDER BY id
+		FOR UPDATE;`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var ended []*model.Subscription
+	for rows.Next() {
+		subscription := new(model.Subscription)
+		if err := rows.Scan(&subscription.ID, &subscription.UserId, &subscription.ServiceName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ended = append(ended, subscription)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range ended {
+		current, err := snapshot(ctx, tx, subscription.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := record(ctx, tx, subscription.ID, model.ActionEnd, current, current); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(ended) > 0 {
+		logger.Info(fmt.Sprintf("%d subscriptions ended", len(ended)))
+	}
+	return ended, nil
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context, filter *model.Filter) ([]*model.Subscription, error) {
+	var subscriptions []*model.Subscription
+	const query = snapshotAsOf + `
+		SELECT id, service_name, price, user_id, start_date, end_date, deleted_at, status, trial_end_date
+		FROM snapshot
+		WHERE ($2 = '' OR user_id = $2)
+		  AND ($3 OR deleted_at IS NULL)
+		  AND ($4::date IS NULL OR (status = 'trial' AND trial_end_date <= $4))
+		  AND id > $5
+		  AND ($7::text[] IS NULL OR user_id = ANY ($7))
+		ORDER BY id
+		LIMIT $6;`
+
+	var limit *int
+	if filter.Limit > 0 {
+		limit = &filter.Limit
+	}
 
-	rows, err := r.postgresDB.Query(ctx, query)
+	rows, err := r.postgresDB.Query(
+		ctx,
+		query,
+		filter.AsOf,
+		filter.UserId,
+		filter.IncludeDeleted,
+		filter.TrialEndsBy,
+		filter.AfterId,
+		limit,
+		filter.UserIds,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +485,9 @@ func (r *SubscriptionRepository) List(ctx context.Context) ([]*model.Subscriptio
 			&subscription.UserId,
 			&subscription.StartDate,
 			&subscription.EndDate,
+			&subscription.DeletedAt,
+			&subscription.Status,
+			&subscription.TrialEndDate,
 		); err != nil {
 			return nil, err
 		}
@@ -150,24 +502,30 @@ func (r *SubscriptionRepository) List(ctx context.Context) ([]*model.Subscriptio
 	return subscriptions, nil
 }
 
-func (r *SubscriptionRepository) Total(ctx context.Context, subscription *model.Subscription) (int64, error) {
+// Total sums the cost of the subscriptions of the user over the range. The current state is read from the monthly
+// costs maintained by the subscription_monthly_costs_track trigger; past states and deleted subscriptions are
+// computed from the rows themselves.
+func (r *SubscriptionRepository) Total(ctx context.Context, subscription *model.Subscription, filter *model.Filter) (int64, error) {
+	if filter.AsOf == nil && !filter.IncludeDeleted && subscription.EndDate != nil {
+		return r.totalFromCosts(ctx, subscription)
+	}
+
+	return r.totalLive(ctx, subscription, filter)
+}
+
+// totalFromCosts adds up every change of the monthly cost before the end of the range, weighted by the number of
+// months of the range it applies to.
+func (r *SubscriptionRepository) totalFromCosts(ctx context.Context, subscription *model.Subscription) (int64, error) {
 	var total int64
-	const query = ` 
-		SELECT sum((
-		           extract(YEAR FROM age(
-		                   CASE WHEN end_date IS NULL OR end_date >= $4 THEN $4 ELSE end_date END,
-		                   CASE WHEN start_date <= $3 THEN $3 ELSE start_date END
-		                             )) * 12 +
-		           extract(MONTH FROM age(
-		                   CASE WHEN end_date IS NULL OR end_date >= $4 THEN $4 ELSE end_date END,
-		                   CASE WHEN start_date <= $3 THEN $3 ELSE start_date END
-		                              ))
-		           ) * price)
-		FROM subscriptions
+	const query = `
+		SELECT coalesce(sum((
+		                    extract(YEAR FROM age($4, greatest(month, $3::date))) * 12 +
+		                    extract(MONTH FROM age($4, greatest(month, $3::date)))
+		                    ) * delta), 0)
+		FROM subscription_monthly_costs
 		WHERE user_id = $1
-		  AND service_name = $2
-		  AND start_date < $4
-		  AND (end_date IS NULL OR end_date > $3);`
+		  AND ($2 = '' OR service_name = $2)
+		  AND month < $4;`
 
 	if err := r.postgresDB.QueryRow(
 		ctx,
@@ -182,3 +540,260 @@ func (r *SubscriptionRepository) Total(ctx context.Context, subscription *model.
 
 	return total, nil
 }
+
+// Totals computes the totals of the current state of the subscriptions, each a range of a user and a service as
+// Total takes, in one query over the monthly costs. Every range must have an end.
+func (r *SubscriptionRepository) Totals(ctx context.Context, subscriptions []*model.Subscription) ([]int64, error) {
+	const query = `
+		SELECT coalesce(sum((
+		                    extract(YEAR FROM age(r.end_date, greatest(c.month, r.start_date))) * 12 +
+		                    extract(MONTH FROM age(r.end_date, greatest(c.month, r.start_date)))
+		                    ) * c.delta), 0)
+		FROM unnest($1::text[], $2::text[], $3::date[], $4::date[])
+		         WITH ORDINALITY AS r(user_id, service_name, start_date, end_date, i)
+		         LEFT JOIN subscription_monthly_costs c
+		                   ON c.user_id = r.user_id
+		                       AND (r.service_name = '' OR c.service_name = r.service_name)
+		                       AND c.month < r.end_date
+		GROUP BY r.i
+		ORDER BY r.i;`
+
+	userIds := make([]string, len(subscriptions))
+	serviceNames := make([]string, len(subscriptions))
+	startDates := make([]time.Time, len(subscriptions))
+	endDates := make([]time.Time, len(subscriptions))
+	for i, subscription := range subscriptions {
+		userIds[i] = subscription.UserId
+		serviceNames[i] = subscription.ServiceName
+		startDates[i] = subscription.StartDate
+		endDates[i] = *subscription.EndDate
+	}
+
+	rows, err := r.postgresDB.Query(ctx, query, userIds, serviceNames, startDates, endDates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]int64, 0, len(subscriptions))
+	for rows.Next() {
+		var total int64
+		if err := rows.Scan(&total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// totalLive adds up the monthly cost changes that subscription_costs computes for the state at filter.AsOf, the
+// same way totalFromCosts weights them, over the subscriptions the user owned or shared then.
+func (r *SubscriptionRepository) totalLive(ctx context.Context, subscription *model.Subscription, filter *model.Filter) (int64, error) {
+	var total int64
+	const query = snapshotAsOf + `
+		SELECT coalesce(sum((
+		                    extract(YEAR FROM age($5, greatest(c.month, $4::date))) * 12 +
+		                    extract(MONTH FROM age($5, greatest(c.month, $4::date)))
+		                    ) * c.delta), 0)
+		FROM (SELECT id
+		      FROM snapshot
+		      WHERE user_id = $2
+		      UNION
+		      SELECT subscription_id
+		      FROM subscription_members
+		      WHERE user_id = $2
+		        AND ($1::timestamptz IS NULL OR created_at <= $1)) AS s
+		         CROSS JOIN LATERAL subscription_costs(s.id, $1, $6) c
+		WHERE c.user_id = $2
+		  AND ($3 = '' OR c.service_name = $3)
+		  AND c.month < $5;`
+
+	if err := r.postgresDB.QueryRow(
+		ctx,
+		query,
+		filter.AsOf,
+		subscription.UserId,
+		subscription.ServiceName,
+		subscription.StartDate,
+		subscription.EndDate,
+		filter.IncludeDeleted,
+	).Scan(&total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Pause stops billing the subscription from the month from until it is resumed.
+func (r *SubscriptionRepository) Pause(ctx context.Context, subscription *model.Subscription, from time.Time) error {
+	const query = `
+		INSERT INTO subscription_pauses (subscription_id, start_month)
+		VALUES ($1, $2);`
+
+	return r.transition(ctx, subscription, model.ActionPause, model.StatusPaused, nil, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, subscription.ID, from)
+		return err
+	})
+}
+
+// Resume closes the open pause of the subscription, billing it again from the month at. A pause that has not
+// started by then is left empty.
+func (r *SubscriptionRepository) Resume(ctx context.Context, subscription *model.Subscription, at time.Time) error {
+	const query = `
+		UPDATE subscription_pauses
+		SET end_month = greatest(start_month, $2), resumed_at = now()
+		WHERE subscription_id = $1
+		  AND resumed_at IS NULL;`
+
+	return r.transition(ctx, subscription, model.ActionResume, model.StatusActive, nil, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, subscription.ID, at)
+		return err
+	})
+}
+
+// Cancel ends the subscription on endDate.
+func (r *SubscriptionRepository) Cancel(ctx context.Context, subscription *model.Subscription, endDate time.Time) error {
+	return r.transition(ctx, subscription, model.ActionCancel, model.StatusCancelled, &endDate, nil)
+}
+
+// transition moves the subscription from the status it was read with to the next one, setting its end date when
+// given, and runs apply in the same transaction. A subscription whose status has changed since it was read is
+// rejected with err_msg.InvalidTransition.
+func (r *SubscriptionRepository) transition(ctx context.Context, subscription *model.Subscription, action, status string, endDate *time.Time, apply func(tx pgx.Tx) error) error {
+	var after []byte
+	const query = `
+		UPDATE subscriptions
+		SET status = $3, end_date = coalesce($4, end_date)
+		WHERE id = $1
+		  AND status = $2
+		  AND deleted_at IS NULL
+		RETURNING to_jsonb(subscriptions.*);`
+
+	tx, err := r.postgresDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := snapshot(ctx, tx, subscription.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(ctx, query, subscription.ID, subscription.Status, status, endDate).Scan(&after); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err_msg.InvalidTransition
+		}
+		return err
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := record(ctx, tx, subscription.ID, action, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("subscription with id %d is %s", subscription.ID, status))
+	return nil
+}
+
+// overlap turns a violation of noOverlapConstraint by the subscription into an err_msg.OverlapError naming the
+// subscription it overlaps. Other errors are returned as they are.
+func (r *SubscriptionRepository) overlap(ctx context.Context, err error, subscription *model.Subscription) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.ConstraintName != noOverlapConstraint {
+		return err
+	}
+
+	var overlappingId int64
+	const query = `
+		SELECT id
+		FROM subscriptions
+		WHERE user_id = $1
+		  AND service_name = $2
+		  AND id <> $3
+		  AND deleted_at IS NULL
+		  AND no_overlap
+		  AND daterange(start_date, end_date) && daterange($4::date, $5::date)
+		ORDER BY id
+		LIMIT 1;`
+
+	if err := r.postgresDB.QueryRow(
+		ctx,
+		query,
+		subscription.UserId,
+		subscription.ServiceName,
+		subscription.ID,
+		subscription.StartDate,
+		subscription.EndDate,
+	).Scan(&overlappingId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err_msg.Overlap
+		}
+		return err
+	}
+
+	return &err_msg.OverlapError{SubscriptionId: overlappingId}
+}
+
+// record writes the audit entry, the outbox events, the webhook deliveries and the emails of a mutation
+// within its transaction.
+func record(ctx context.Context, tx pgx.Tx, subscriptionId int64, action string, before, after []byte) error {
+	if err := audit(ctx, tx, subscriptionId, action, before, after); err != nil {
+		return err
+	}
+
+	if action == model.ActionCreate {
+		if err := enqueueCreatedEmail(ctx, tx, after); err != nil {
+			return err
+		}
+	}
+
+	changes, err := events.FromChange(action, before, after)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range changes {
+		if ended, ok := event.(*events.SubscriptionEnded); ok {
+			if err := markEnded(ctx, tx, subscriptionId, ended.EndDate); err != nil {
+				return err
+			}
+		}
+
+		if err := appendOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+
+		if err := enqueueWebhooks(ctx, tx, event.Type(), "subscription", after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markEnded records that the end date of the subscription has been announced, so that EndSubscriptions does not
+// announce it again.
+func markEnded(ctx context.Context, tx pgx.Tx, subscriptionId int64, endDate string) error {
+	const query = `
+		INSERT INTO subscription_endings (subscription_id, end_date)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`
+
+	_, err := tx.Exec(ctx, query, subscriptionId, endDate)
+	return err
+}
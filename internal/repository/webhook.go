@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type WebhookRepository struct {
+	postgresDB *pgxpool.Pool
+}
+
+func NewWebhookRepository(postgresDB *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		postgresDB: postgresDB,
+	}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	const query = `
+		INSERT INTO webhooks (url, events, secret)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;`
+
+	if err := r.postgresDB.QueryRow(ctx, query, webhook.URL, webhook.Events, webhook.Secret).Scan(
+		&webhook.ID,
+		&webhook.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("webhook with id %d created", webhook.ID))
+	return nil
+}
+
+func (r *WebhookRepository) Read(ctx context.Context, webhookId int64) (*model.Webhook, error) {
+	webhook := new(model.Webhook)
+	const query = `
+		SELECT id, url, events, created_at
+		FROM webhooks
+		WHERE id = $1;`
+
+	if err := r.postgresDB.QueryRow(ctx, query, webhookId).Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Events,
+		&webhook.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err_msg.NoRowsAffected
+		}
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("webhook with id %d read", webhookId))
+	return webhook, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	const query = `
+		UPDATE webhooks
+		SET url = $2, events = $3, secret = CASE WHEN $4 = '' THEN secret ELSE $4 END
+		WHERE id = $1;`
+
+	tag, err := r.postgresDB.Exec(ctx, query, webhook.ID, webhook.URL, webhook.Events, webhook.Secret)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return err_msg.NoRowsAffected
+	}
+
+	logger.Info(fmt.Sprintf("webhook with id %d updated", webhook.ID))
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, webhookId int64) error {
+	const query = `
+		DELETE FROM webhooks
+		WHERE id = $1;`
+
+	tag, err := r.postgresDB.Exec(ctx, query, webhookId)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return err_msg.NoRowsAffected
+	}
+
+	logger.Info(fmt.Sprintf("webhook with id %d deleted", webhookId))
+	return nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	const query = `
+		SELECT id, url, events, created_at
+		FROM webhooks
+		ORDER BY id;`
+
+	rows, err := r.postgresDB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		webhook := new(model.Webhook)
+		if err := rows.Scan(
+			&webhook.ID,
+			&webhook.URL,
+			&webhook.Events,
+			&webhook.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d webhooks listed", len(webhooks)))
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Deliveries(ctx context.Context, webhookId int64, status string) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	const query = `
+		SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, coalesce(last_error, ''), created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		  AND ($2 = '' OR status = $2)
+		ORDER BY id;`
+
+	rows, err := r.postgresDB.Query(ctx, query, webhookId, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery := new(model.WebhookDelivery)
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookId,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("%d deliveries of webhook with id %d listed", len(deliveries), webhookId))
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) Redeliver(ctx context.Context, webhookId, deliveryId int64) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = NULL
+		WHERE id = $1
+		  AND webhook_id = $2;`
+
+	tag, err := r.postgresDB.Exec(ctx, query, deliveryId, webhookId)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return err_msg.NoRowsAffected
+	}
+
+	logger.Info(fmt.Sprintf("delivery with id %d of webhook with id %d scheduled for redelivery", deliveryId, webhookId))
+	return nil
+}
+
+// Claim leases due deliveries so that concurrent workers do not pick them up until the lease expires.
+func (r *WebhookRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	const query = `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + $2::interval
+		FROM webhooks w
+		WHERE w.id = d.webhook_id
+		  AND d.id IN (SELECT id
+		               FROM webhook_deliveries
+		               WHERE status = 'pending'
+		                 AND next_attempt_at <= now()
+		               ORDER BY id
+		               LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret;`
+
+	rows, err := r.postgresDB.Query(ctx, query, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery := new(model.WebhookDelivery)
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookId,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Attempts,
+			&delivery.URL,
+			&delivery.Secret,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, deliveryId int64) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = now()
+		WHERE id = $1;`
+
+	if _, err := r.postgresDB.Exec(ctx, query, deliveryId); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("delivery with id %d delivered", deliveryId))
+	return nil
+}
+
+// MarkFailed schedules the next attempt, or dead-letters the delivery when nextAttemptAt is nil.
+func (r *WebhookRepository) MarkFailed(ctx context.Context, deliveryId int64, lastError string, nextAttemptAt *time.Time) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $3::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+		    attempts = attempts + 1,
+		    last_error = $2,
+		    next_attempt_at = coalesce($3, next_attempt_at)
+		WHERE id = $1;`
+
+	if _, err := r.postgresDB.Exec(ctx, query, deliveryId, lastError, nextAttemptAt); err != nil {
+		return err
+	}
+
+	if nextAttemptAt == nil {
+		logger.Info(fmt.Sprintf("delivery with id %d dead-lettered: %s", deliveryId, lastError))
+	}
+	return nil
+}
+
+// enqueueWebhooks writes a delivery for every webhook subscribed to the event within the mutation transaction,
+// carrying the payload under the key.
+func enqueueWebhooks(ctx context.Context, tx pgx.Tx, event, key string, payload []byte) error {
+	const query = `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $1, jsonb_build_object('event', $1::text, $3::text, $2::jsonb, 'occurred_at', now())
+		FROM webhooks
+		WHERE $1 = ANY (events)
+		   OR '*' = ANY (events);`
+
+	_, err := tx.Exec(ctx, query, event, payload, key)
+	return err
+}
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/lib/request_id"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryAuth identifies the caller of a call, as auth.Middleware and request_id.Middleware do for HTTP requests.
+func UnaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := withIdentity(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return handler(ctx, req)
+}
+
+func StreamAuth(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withIdentity(ss.Context())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+func UnaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func StreamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+// UnaryErrors turns the errors of the service into status codes.
+func UnaryErrors(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(err)
+}
+
+func StreamErrors(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(handler(srv, ss))
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// withIdentity trusts the identity metadata set by the authenticating gateway, as auth.Middleware trusts its headers,
+// and carries the caller's request id into the audit log.
+func withIdentity(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := first(md, auth.UserIdHeader)
+	role := first(md, auth.UserRoleHeader)
+	if err := auth.ValidateUserId(id); err != nil {
+		return nil, err
+	}
+	if id != "" && role != "" {
+		ctx = auth.WithActor(ctx, &auth.Actor{ID: id, Role: role})
+	}
+
+	requestId := first(md, request_id.Header)
+	if !request_id.Valid(requestId) {
+		requestId = request_id.New()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(request_id.Header, requestId))
+
+	return request_id.WithRequestId(ctx, requestId), nil
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(strings.ToLower(key)); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	msg := fmt.Sprintf("grpc %s %s in %s, request: %s",
+		method, status.Code(err), time.Since(start), request_id.FromContext(ctx))
+	if err != nil {
+		logger.Error(fmt.Errorf("%s: %w", msg, err))
+		return
+	}
+
+	logger.Info(msg)
+}
+
+// toStatus maps service errors to status codes the way errorStatus maps them to HTTP statuses.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(errorCode(err), err.Error())
+}
+
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, err_msg.Unauthorized):
+		return codes.Unauthenticated
+	case errors.Is(err, err_msg.Forbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, err_msg.RequestBodyIsEmpty),
+		errors.Is(err, err_msg.LaterDate),
+		errors.Is(err, err_msg.InvalidWebhook),
+		errors.Is(err, err_msg.InvalidPreference),
+		errors.Is(err, err_msg.InvalidBudget),
+		errors.Is(err, err_msg.InvalidReport),
+		errors.Is(err, err_msg.InvalidCancel),
+		errors.Is(err, err_msg.InvalidTrial),
+		errors.Is(err, err_msg.InvalidDiscount),
+		errors.Is(err, err_msg.InvalidMember),
+		errors.Is(err, err_msg.InvalidPrice),
+		errors.Is(err, err_msg.InvalidPage),
+		errors.Is(err, err_msg.InvalidRequest),
+		errors.As(err, new(*time.ParseError)),
+		errors.As(err, new(*strconv.NumError)):
+		return codes.InvalidArgument
+	case errors.Is(err, err_msg.NoRowsAffected),
+		errors.Is(err, pgx.ErrNoRows):
+		return codes.NotFound
+	case errors.Is(err, err_msg.Overlap):
+		return codes.AlreadyExists
+	case errors.Is(err, err_msg.InvalidTransition):
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,183 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	subscriptionv1 "github.com/oatsmoke/20250905/api/subscription/v1"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listPageSize is the number of subscriptions ListSubscriptions reads at a time while streaming them.
+const listPageSize = 100
+
+type Subscription interface {
+	Create(ctx context.Context, data *model.ExternalData) error
+	Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.SubscriptionDetail, error)
+	Update(ctx context.Context, subscriptionId int64, data *model.ExternalData) error
+	Delete(ctx context.Context, subscriptionId int64) error
+	List(ctx context.Context, filter *model.Filter) ([]*model.ExternalData, error)
+	Total(ctx context.Context, data *model.ExternalData, filter *model.Filter) (int64, error)
+}
+
+type SubscriptionServer struct {
+	subscriptionv1.UnimplementedSubscriptionServiceServer
+	subscriptionService Subscription
+}
+
+func NewSubscriptionServer(subscriptionService Subscription) *SubscriptionServer {
+	return &SubscriptionServer{
+		subscriptionService: subscriptionService,
+	}
+}
+
+func (s *SubscriptionServer) CreateSubscription(ctx context.Context, req *subscriptionv1.CreateSubscriptionRequest) (*subscriptionv1.CreateSubscriptionResponse, error) {
+	data, err := mapIn(req.GetSubscription())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.subscriptionService.Create(ctx, data); err != nil {
+		return nil, err
+	}
+
+	return &subscriptionv1.CreateSubscriptionResponse{}, nil
+}
+
+func (s *SubscriptionServer) GetSubscription(ctx context.Context, req *subscriptionv1.GetSubscriptionRequest) (*subscriptionv1.GetSubscriptionResponse, error) {
+	detail, err := s.subscriptionService.Read(ctx, req.GetId(), &model.Filter{
+		IncludeDeleted: req.GetIncludeDeleted(),
+		AsOf:           asOf(req.GetAsOf()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]*subscriptionv1.PriceChange, len(detail.EffectivePrices))
+	for i, price := range detail.EffectivePrices {
+		prices[i] = &subscriptionv1.PriceChange{Month: price.Month, Price: price.Price}
+	}
+
+	return &subscriptionv1.GetSubscriptionResponse{
+		Subscription:    mapOut(&detail.ExternalData),
+		EffectivePrices: prices,
+	}, nil
+}
+
+func (s *SubscriptionServer) UpdateSubscription(ctx context.Context, req *subscriptionv1.UpdateSubscriptionRequest) (*subscriptionv1.UpdateSubscriptionResponse, error) {
+	data, err := mapIn(req.GetSubscription())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.subscriptionService.Update(ctx, req.GetId(), data); err != nil {
+		return nil, err
+	}
+
+	return &subscriptionv1.UpdateSubscriptionResponse{}, nil
+}
+
+func (s *SubscriptionServer) DeleteSubscription(ctx context.Context, req *subscriptionv1.DeleteSubscriptionRequest) (*subscriptionv1.DeleteSubscriptionResponse, error) {
+	if err := s.subscriptionService.Delete(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &subscriptionv1.DeleteSubscriptionResponse{}, nil
+}
+
+// ListSubscriptions reads the subscriptions a page at a time, so a long list is neither held in memory nor read in
+// one query.
+func (s *SubscriptionServer) ListSubscriptions(req *subscriptionv1.ListSubscriptionsRequest, stream subscriptionv1.SubscriptionService_ListSubscriptionsServer) error {
+	filter := &model.Filter{
+		UserId:         req.GetUserId(),
+		IncludeDeleted: req.GetIncludeDeleted(),
+		AsOf:           asOf(req.GetAsOf()),
+		Limit:          listPageSize,
+	}
+
+	for {
+		// List may narrow the filter to the caller, so each page gets a copy.
+		page := *filter
+		list, err := s.subscriptionService.List(stream.Context(), &page)
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range list {
+			if err := stream.Send(&subscriptionv1.ListSubscriptionsResponse{Subscription: mapOut(subscription)}); err != nil {
+				return err
+			}
+		}
+
+		if len(list) < listPageSize {
+			return nil
+		}
+		filter.AfterId = list[len(list)-1].ID
+	}
+}
+
+func (s *SubscriptionServer) Total(ctx context.Context, req *subscriptionv1.TotalRequest) (*subscriptionv1.TotalResponse, error) {
+	if req.GetUserId() == "" || req.GetServiceName() == "" || req.GetStartDate() == "" || req.GetEndDate() == "" {
+		return nil, err_msg.RequestBodyIsEmpty
+	}
+
+	total, err := s.subscriptionService.Total(ctx, &model.ExternalData{
+		UserId:      req.GetUserId(),
+		ServiceName: req.GetServiceName(),
+		StartDate:   req.GetStartDate(),
+		EndDate:     req.GetEndDate(),
+	}, &model.Filter{
+		IncludeDeleted: req.GetIncludeDeleted(),
+		AsOf:           asOf(req.GetAsOf()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscriptionv1.TotalResponse{Total: total}, nil
+}
+
+func mapIn(subscription *subscriptionv1.Subscription) (*model.ExternalData, error) {
+	if subscription == nil {
+		return nil, err_msg.RequestBodyIsEmpty
+	}
+
+	data := &model.ExternalData{
+		ServiceName:  subscription.GetServiceName(),
+		Price:        subscription.GetPrice(),
+		UserId:       subscription.GetUserId(),
+		StartDate:    subscription.GetStartDate(),
+		EndDate:      subscription.GetEndDate(),
+		TrialEndDate: subscription.GetTrialEndDate(),
+	}
+	if *data == (model.ExternalData{}) {
+		return nil, err_msg.RequestBodyIsEmpty
+	}
+
+	return data, nil
+}
+
+func mapOut(data *model.ExternalData) *subscriptionv1.Subscription {
+	return &subscriptionv1.Subscription{
+		Id:           data.ID,
+		ServiceName:  data.ServiceName,
+		Price:        data.Price,
+		UserId:       data.UserId,
+		StartDate:    data.StartDate,
+		EndDate:      data.EndDate,
+		DeletedAt:    data.DeletedAt,
+		Status:       data.Status,
+		TrialEndDate: data.TrialEndDate,
+	}
+}
+
+func asOf(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+
+	t := timestamp.AsTime()
+	return &t
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Budget interface {
+	Create(ctx context.Context, budget *model.Budget) error
+	Read(ctx context.Context, userId string, budgetId int64) (*model.Budget, error)
+	Update(ctx context.Context, budget *model.Budget) error
+	Delete(ctx context.Context, userId string, budgetId int64) error
+	List(ctx context.Context, userId string) ([]*model.Budget, error)
+	ListOf(ctx context.Context, userIds []string) ([]*model.Budget, error)
+	Alert(ctx context.Context, status *model.BudgetStatus, threshold int32) (bool, error)
+}
+
+type Totaler interface {
+	Totals(ctx context.Context, subscriptions []*model.Subscription) ([]int64, error)
+}
+
+type BudgetService struct {
+	budgetRepository Budget
+	totaler          Totaler
+	policy           *Policy
+}
+
+func NewBudgetService(budgetRepository Budget, totaler Totaler, policy *Policy) *BudgetService {
+	return &BudgetService{
+		budgetRepository: budgetRepository,
+		totaler:          totaler,
+		policy:           policy,
+	}
+}
+
+func (s *BudgetService) Create(ctx context.Context, userId string, budget *model.Budget) error {
+	if err := s.policy.Authorize(ctx, SubscriptionWrite, userId); err != nil {
+		return err
+	}
+	budget.UserId = userId
+
+	if err := validateBudget(budget); err != nil {
+		return err
+	}
+
+	return s.budgetRepository.Create(ctx, budget)
+}
+
+func (s *BudgetService) Read(ctx context.Context, userId string, budgetId int64) (*model.Budget, error) {
+	if err := s.policy.Authorize(ctx, SubscriptionRead, userId); err != nil {
+		return nil, err
+	}
+
+	return s.budgetRepository.Read(ctx, userId, budgetId)
+}
+
+func (s *BudgetService) Update(ctx context.Context, userId string, budgetId int64, budget *model.Budget) error {
+	if err := s.policy.Authorize(ctx, SubscriptionWrite, userId); err != nil {
+		return err
+	}
+	budget.ID = budgetId
+	budget.UserId = userId
+
+	if err := validateBudget(budget); err != nil {
+		return err
+	}
+
+	return s.budgetRepository.Update(ctx, budget)
+}
+
+func (s *BudgetService) Delete(ctx context.Context, userId string, budgetId int64) error {
+	if err := s.policy.Authorize(ctx, SubscriptionWrite, userId); err != nil {
+		return err
+	}
+
+	return s.budgetRepository.Delete(ctx, userId, budgetId)
+}
+
+func (s *BudgetService) List(ctx context.Context, userId string) ([]*model.Budget, error) {
+	if err := s.policy.Authorize(ctx, SubscriptionRead, userId); err != nil {
+		return nil, err
+	}
+
+	return s.budgetRepository.List(ctx, userId)
+}
+
+// Status reports the spending of the current period against every budget of the user.
+func (s *BudgetService) Status(ctx context.Context, userId string) ([]*model.BudgetStatus, error) {
+	if err := s.policy.Authorize(ctx, ReportRead, userId); err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budgetRepository.List(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		status, err := s.status(ctx, budget, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// StatusOf reports the spending of the current period against every budget of each of the users, reading the
+// budgets and the spending in one query each. errs[i] is set when the status of userIds[i] cannot be reported,
+// without failing the other users.
+func (s *BudgetService) StatusOf(ctx context.Context, userIds []string) ([][]*model.BudgetStatus, []error) {
+	statuses := make([][]*model.BudgetStatus, len(userIds))
+	errs := make([]error, len(userIds))
+
+	var allowed []string
+	for i, userId := range userIds {
+		if errs[i] = s.policy.Authorize(ctx, ReportRead, userId); errs[i] == nil {
+			allowed = append(allowed, userId)
+		}
+	}
+
+	fail := func(err error) ([][]*model.BudgetStatus, []error) {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return statuses, errs
+	}
+
+	if len(allowed) == 0 {
+		return statuses, errs
+	}
+
+	budgets, err := s.budgetRepository.ListOf(ctx, allowed)
+	if err != nil {
+		return fail(err)
+	}
+
+	// The ranges of all the budgets are totalled at once, counts[i] of them are of budgets[i].
+	now := time.Now()
+	var ranges []*model.Subscription
+	counts := make([]int, len(budgets))
+	for i, budget := range budgets {
+		budgetRanges := budgetRanges(budget, now)
+		ranges = append(ranges, budgetRanges...)
+		counts[i] = len(budgetRanges)
+	}
+
+	totals, err := s.totaler.Totals(ctx, ranges)
+	if err != nil {
+		return fail(err)
+	}
+
+	byUser := make(map[string][]*model.BudgetStatus, len(allowed))
+	for i, budget := range budgets {
+		status := budgetStatus(budget, ranges[0], sum(totals[:counts[i]]))
+		ranges, totals = ranges[counts[i]:], totals[counts[i]:]
+		byUser[budget.UserId] = append(byUser[budget.UserId], status)
+	}
+
+	for i, userId := range userIds {
+		if errs[i] == nil {
+			statuses[i] = byUser[userId]
+			if statuses[i] == nil {
+				statuses[i] = []*model.BudgetStatus{}
+			}
+		}
+	}
+
+	return statuses, errs
+}
+
+// Evaluate checks every budget at now and raises an alert for each newly crossed threshold.
+// It runs on behalf of the system, so it is not authorized.
+func (s *BudgetService) Evaluate(ctx context.Context, now time.Time) (int, error) {
+	var alerts int
+
+	budgets, err := s.budgetRepository.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, budget := range budgets {
+		status, err := s.status(ctx, budget, now)
+		if err != nil {
+			return alerts, err
+		}
+
+		for _, threshold := range status.Crossed {
+			created, err := s.budgetRepository.Alert(ctx, status, threshold)
+			if err != nil {
+				return alerts, err
+			}
+
+			if created {
+				alerts++
+			}
+		}
+	}
+
+	if alerts > 0 {
+		logger.Info(fmt.Sprintf("%d budget alerts raised", alerts))
+	}
+	return alerts, nil
+}
+
+// status computes the spending of the budget period containing now with the same calculation as the total report.
+func (s *BudgetService) status(ctx context.Context, budget *model.Budget, now time.Time) (*model.BudgetStatus, error) {
+	ranges := budgetRanges(budget, now)
+	totals, err := s.totaler.Totals(ctx, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	return budgetStatus(budget, ranges[0], sum(totals)), nil
+}
+
+// budgetRanges are the ranges of the subscriptions the budget covers over its period containing now, as the total
+// report takes them: the service of the budget, all services, or one range per service of its category.
+func budgetRanges(budget *model.Budget, now time.Time) []*model.Subscription {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	if budget.Period == model.PeriodYear {
+		start = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(1, 0, 0)
+	}
+
+	services := []string{budget.ServiceName}
+	if budget.Category != "" {
+		services = budget.Services
+	}
+
+	ranges := make([]*model.Subscription, len(services))
+	for i, service := range services {
+		ranges[i] = &model.Subscription{
+			UserId:      budget.UserId,
+			ServiceName: service,
+			StartDate:   start,
+			EndDate:     &end,
+		}
+	}
+
+	return ranges
+}
+
+func sum(totals []int64) int64 {
+	var spent int64
+	for _, total := range totals {
+		spent += total
+	}
+
+	return spent
+}
+
+func budgetStatus(budget *model.Budget, period *model.Subscription, spent int64) *model.BudgetStatus {
+	status := &model.BudgetStatus{
+		Budget:      budget,
+		PeriodStart: period.StartDate.Format(time.DateOnly),
+		PeriodEnd:   period.EndDate.Format(time.DateOnly),
+		Spent:       spent,
+		PercentUsed: math.Round(float64(spent)*10000/float64(budget.Amount)) / 100,
+		Crossed:     []int32{},
+	}
+
+	for _, threshold := range budget.Thresholds {
+		if spent*100 >= budget.Amount*int64(threshold) {
+			status.Crossed = append(status.Crossed, threshold)
+		}
+	}
+
+	return status
+}
+
+func validateBudget(budget *model.Budget) error {
+	if budget.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", err_msg.InvalidBudget)
+	}
+
+	if budget.Category == "" && len(budget.Services) > 0 {
+		return fmt.Errorf("%w: services require a category", err_msg.InvalidBudget)
+	}
+	if budget.Category != "" {
+		if budget.ServiceName != "" {
+			return fmt.Errorf("%w: a budget has either a service_name or a category", err_msg.InvalidBudget)
+		}
+		if len(budget.Services) == 0 {
+			return fmt.Errorf("%w: a category must list its services", err_msg.InvalidBudget)
+		}
+		if slices.Contains(budget.Services, "") {
+			return fmt.Errorf("%w: services must be named", err_msg.InvalidBudget)
+		}
+	}
+	if budget.Services == nil {
+		budget.Services = []string{}
+	}
+	slices.Sort(budget.Services)
+	budget.Services = slices.Compact(budget.Services)
+
+	if budget.Currency == "" {
+		budget.Currency = model.DefaultCurrency
+	}
+	if len(budget.Currency) != 3 || strings.Trim(budget.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", err_msg.InvalidBudget)
+	}
+
+	if budget.Period == "" {
+		budget.Period = model.PeriodMonth
+	}
+	if budget.Period != model.PeriodMonth && budget.Period != model.PeriodYear {
+		return fmt.Errorf("%w: unknown period %q", err_msg.InvalidBudget, budget.Period)
+	}
+
+	if len(budget.Thresholds) == 0 {
+		budget.Thresholds = slices.Clone(model.DefaultThresholds)
+	}
+	for _, threshold := range budget.Thresholds {
+		if threshold <= 0 || threshold > 1000 {
+			return fmt.Errorf("%w: threshold %d is out of range 1..1000", err_msg.InvalidBudget, threshold)
+		}
+	}
+	slices.Sort(budget.Thresholds)
+	budget.Thresholds = slices.Compact(budget.Thresholds)
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+)
+
+type DebugService struct {
+	policy *Policy
+}
+
+func NewDebugService(policy *Policy) *DebugService {
+	return &DebugService{
+		policy: policy,
+	}
+}
+
+// Authorize checks that the actor may read the metrics of the process, which cover all users.
+func (s *DebugService) Authorize(ctx context.Context) error {
+	return s.policy.Allow(ctx, DebugRead)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oatsmoke/20250905/internal/lib/auth"
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+type Permission string
+
+const (
+	SubscriptionRead   Permission = "subscription:read"
+	SubscriptionWrite  Permission = "subscription:write"
+	SubscriptionDelete Permission = "subscription:delete"
+	SubscriptionAdmin  Permission = "subscription:admin"
+	ReportRead         Permission = "report:read"
+	WebhookManage      Permission = "webhook:manage"
+	AnalyticsRead      Permission = "analytics:read"
+	DebugRead          Permission = "debug:read"
+)
+
+type Scope string
+
+const (
+	ScopeOwn Scope = "own"
+	ScopeAny Scope = "any"
+)
+
+type Policy struct {
+	Roles map[string]map[Permission]Scope `json:"roles"`
+}
+
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Roles: map[string]map[Permission]Scope{
+			"admin": {
+				SubscriptionRead:   ScopeAny,
+				SubscriptionWrite:  ScopeAny,
+				SubscriptionDelete: ScopeAny,
+				SubscriptionAdmin:  ScopeAny,
+				ReportRead:         ScopeAny,
+				WebhookManage:      ScopeAny,
+				AnalyticsRead:      ScopeAny,
+				DebugRead:          ScopeAny,
+			},
+			"support": {
+				SubscriptionRead: ScopeAny,
+			},
+			"finance": {
+				SubscriptionRead: ScopeAny,
+				ReportRead:       ScopeAny,
+				AnalyticsRead:    ScopeAny,
+			},
+			"user": {
+				SubscriptionRead:   ScopeOwn,
+				SubscriptionWrite:  ScopeOwn,
+				SubscriptionDelete: ScopeOwn,
+				ReportRead:         ScopeOwn,
+			},
+		},
+	}
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := new(Policy)
+	if err := json.Unmarshal(file, policy); err != nil {
+		return nil, err
+	}
+
+	for role, permissions := range policy.Roles {
+		for permission, scope := range permissions {
+			if scope != ScopeOwn && scope != ScopeAny {
+				return nil, fmt.Errorf("role %s: permission %s: unknown scope %q", role, permission, scope)
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) scope(ctx context.Context, permission Permission) (*auth.Actor, Scope, error) {
+	actor, ok := auth.FromContext(ctx)
+	if !ok {
+		logger.Audit("access denied", "permission", permission, "reason", "unauthenticated")
+		return nil, "", err_msg.Unauthorized
+	}
+
+	scope, ok := p.Roles[actor.Role][permission]
+	if !ok {
+		logger.Audit("access denied", "actor", actor.ID, "role", actor.Role, "permission", permission)
+		return nil, "", err_msg.Forbidden
+	}
+
+	return actor, scope, nil
+}
+
+// Allow checks that the actor has permission, for its own records or for any.
+func (p *Policy) Allow(ctx context.Context, permission Permission) error {
+	_, _, err := p.scope(ctx, permission)
+	return err
+}
+
+func (p *Policy) Authorize(ctx context.Context, permission Permission, ownerId string) error {
+	actor, scope, err := p.scope(ctx, permission)
+	if err != nil {
+		return err
+	}
+
+	if scope == ScopeOwn && actor.ID != ownerId {
+		logger.Audit("access denied",
+			"actor", actor.ID,
+			"role", actor.Role,
+			"permission", permission,
+			"owner", ownerId,
+		)
+		return err_msg.Forbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/lib/http_client"
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/oatsmoke/20250905/internal/notifier"
+)
+
+type Preference interface {
+	Read(ctx context.Context, userId string) (*model.Preference, error)
+	Save(ctx context.Context, preference *model.Preference) error
+}
+
+type PreferenceService struct {
+	preferenceRepository Preference
+	policy               *Policy
+}
+
+func NewPreferenceService(preferenceRepository Preference, policy *Policy) *PreferenceService {
+	return &PreferenceService{
+		preferenceRepository: preferenceRepository,
+		policy:               policy,
+	}
+}
+
+func (s *PreferenceService) Read(ctx context.Context, userId string) (*model.Preference, error) {
+	if err := s.policy.Authorize(ctx, SubscriptionRead, userId); err != nil {
+		return nil, err
+	}
+
+	return s.preferenceRepository.Read(ctx, userId)
+}
+
+func (s *PreferenceService) Save(ctx context.Context, userId string, preference *model.Preference) error {
+	if err := s.policy.Authorize(ctx, SubscriptionWrite, userId); err != nil {
+		return err
+	}
+	preference.UserId = userId
+
+	if preference.Locale == "" {
+		preference.Locale = model.DefaultLocale
+	}
+	if !notifier.Supports(preference.Locale) {
+		return fmt.Errorf("%w: unsupported locale %q", err_msg.InvalidPreference, preference.Locale)
+	}
+
+	switch preference.Channel {
+	case model.ChannelLog, model.ChannelNone:
+	case model.ChannelEmail:
+		if _, err := mail.ParseAddress(preference.Address); err != nil {
+			return fmt.Errorf("%w: %v", err_msg.InvalidPreference, err)
+		}
+	case model.ChannelWebhook:
+		endpoint, err := url.Parse(preference.Address)
+		if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+			return fmt.Errorf("%w: address must be an absolute https url", err_msg.InvalidPreference)
+		}
+		// Names are checked when the notifier connects, as they may resolve to another address by then.
+		if addr, err := netip.ParseAddr(endpoint.Hostname()); err == nil && !http_client.Public(addr) {
+			return fmt.Errorf("%w: address must be public", err_msg.InvalidPreference)
+		}
+	default:
+		return fmt.Errorf("%w: unknown channel %q", err_msg.InvalidPreference, preference.Channel)
+	}
+
+	return s.preferenceRepository.Save(ctx, preference)
+}
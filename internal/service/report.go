@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+const (
+	maxForecastMonths  = 120
+	maxAnalyticsMonths = 120
+)
+
+type Report interface {
+	Forecast(ctx context.Context, userId string, from time.Time, months int) ([]*model.MonthlyCost, error)
+	MRR(ctx context.Context, from, to time.Time) ([]*model.MRRMonth, error)
+	Subscribers(ctx context.Context, from, to time.Time) ([]*model.ServiceSubscribers, error)
+	Retention(ctx context.Context, from, to time.Time) ([]*model.Cohort, error)
+	Overlaps(ctx context.Context, userId string) ([]*model.Overlap, error)
+}
+
+type ReportService struct {
+	reportRepository Report
+	policy           *Policy
+}
+
+func NewReportService(reportRepository Report, policy *Policy) *ReportService {
+	return &ReportService{
+		reportRepository: reportRepository,
+		policy:           policy,
+	}
+}
+
+// Forecast projects the spend of the user over the given number of months, starting with the next month,
+// since subscriptions renew on the first day of a month.
+func (s *ReportService) Forecast(ctx context.Context, userId string, months int) (*model.Forecast, error) {
+	if err := s.policy.Authorize(ctx, ReportRead, userId); err != nil {
+		return nil, err
+	}
+
+	if months < 1 || months > maxForecastMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", err_msg.InvalidReport, maxForecastMonths)
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+	costs, err := s.reportRepository.Forecast(ctx, userId, from, months)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &model.Forecast{
+		UserId: userId,
+		Months: costs,
+	}
+	for _, cost := range costs {
+		forecast.Total += cost.Total
+	}
+
+	return forecast, nil
+}
+
+// Overlaps lists the overlapping subscriptions of the user, or of every user when userId is empty.
+func (s *ReportService) Overlaps(ctx context.Context, userId string) ([]*model.Overlap, error) {
+	if err := s.policy.Authorize(ctx, ReportRead, userId); err != nil {
+		return nil, err
+	}
+
+	return s.reportRepository.Overlaps(ctx, userId)
+}
+
+func (s *ReportService) MRR(ctx context.Context, from, to time.Time) ([]*model.MRRMonth, error) {
+	if err := s.authorizeAnalytics(ctx, from, to); err != nil {
+		return nil, err
+	}
+
+	return s.reportRepository.MRR(ctx, from, to)
+}
+
+func (s *ReportService) Subscribers(ctx context.Context, from, to time.Time) ([]*model.ServiceSubscribers, error) {
+	if err := s.authorizeAnalytics(ctx, from, to); err != nil {
+		return nil, err
+	}
+
+	return s.reportRepository.Subscribers(ctx, from, to)
+}
+
+func (s *ReportService) Retention(ctx context.Context, from, to time.Time) ([]*model.Cohort, error) {
+	if err := s.authorizeAnalytics(ctx, from, to); err != nil {
+		return nil, err
+	}
+
+	return s.reportRepository.Retention(ctx, from, to)
+}
+
+// authorizeAnalytics checks access to metrics across all users and bounds the range they are computed over.
+func (s *ReportService) authorizeAnalytics(ctx context.Context, from, to time.Time) error {
+	if err := s.policy.Authorize(ctx, AnalyticsRead, ""); err != nil {
+		return err
+	}
+
+	if from.After(to) {
+		return err_msg.LaterDate
+	}
+
+	if to.After(from.AddDate(0, maxAnalyticsMonths-1, 0)) {
+		return fmt.Errorf("%w: range must not exceed %d months", err_msg.InvalidReport, maxAnalyticsMonths)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+const (
+	streamBuffer      = 64
+	streamReplayLimit = 1000
+	listenRetry       = 5 * time.Second
+)
+
+type EventLog interface {
+	Read(ctx context.Context, eventId int64) (*events.Envelope, error)
+	After(ctx context.Context, afterId int64, filter *events.Filter, limit int) ([]*events.Envelope, error)
+	Listen(ctx context.Context, handle func(eventId int64)) error
+}
+
+// StreamService fans out subscription events to live streams. Every replica listens to Postgres notifications
+// itself, so each stream receives all changes regardless of the replica that made them.
+type StreamService struct {
+	eventLog    EventLog
+	policy      *Policy
+	mu          sync.Mutex
+	subscribers map[chan *events.Envelope]*events.Filter
+}
+
+func NewStreamService(eventLog EventLog, policy *Policy) *StreamService {
+	return &StreamService{
+		eventLog:    eventLog,
+		policy:      policy,
+		subscribers: make(map[chan *events.Envelope]*events.Filter),
+	}
+}
+
+func (s *StreamService) Run(ctx context.Context) {
+	for {
+		err := s.eventLog.Listen(ctx, func(eventId int64) {
+			envelope, err := s.eventLog.Read(ctx, eventId)
+			if err != nil {
+				logger.Error(err)
+				return
+			}
+			s.broadcast(envelope)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetry):
+		}
+	}
+}
+
+// Stream replays the events after lastEventId and then follows live ones until ctx is done.
+// The channel is closed early when the reader falls behind, the client is expected to resume with its last id.
+func (s *StreamService) Stream(ctx context.Context, filter *events.Filter, lastEventId int64) (<-chan *events.Envelope, error) {
+	actor, scope, err := s.policy.scope(ctx, SubscriptionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == ScopeOwn {
+		if filter.UserId != "" && filter.UserId != actor.ID {
+			return nil, s.policy.Authorize(ctx, SubscriptionRead, filter.UserId)
+		}
+		filter.UserId = actor.ID
+	}
+
+	live := s.subscribe(filter)
+
+	out := make(chan *events.Envelope)
+	go func() {
+		defer close(out)
+		defer s.unsubscribe(live)
+
+		sent := lastEventId
+		for sent > 0 {
+			replay, err := s.eventLog.After(ctx, sent, filter, streamReplayLimit)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Error(err)
+				}
+				return
+			}
+
+			for _, envelope := range replay {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- envelope:
+					sent = envelope.ID
+				}
+			}
+
+			if len(replay) < streamReplayLimit {
+				break
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case envelope, ok := <-live:
+				if !ok {
+					return
+				}
+				if envelope.ID <= sent {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- envelope:
+					sent = envelope.ID
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (s *StreamService) subscribe(filter *events.Filter) chan *events.Envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan *events.Envelope, streamBuffer)
+	s.subscribers[ch] = filter
+	return ch
+}
+
+func (s *StreamService) unsubscribe(ch chan *events.Envelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (s *StreamService) broadcast(envelope *events.Envelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch, filter := range s.subscribers {
+		if !filter.Match(envelope) {
+			continue
+		}
+
+		select {
+		case ch <- envelope:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -15,20 +16,46 @@ import (
 
 type Subscription interface {
 	Create(ctx context.Context, subscription *model.Subscription) error
-	Read(ctx context.Context, subscriptionId int64) (*model.Subscription, error)
+	Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.Subscription, error)
 	Update(ctx context.Context, subscription *model.Subscription) error
 	Delete(ctx context.Context, subscriptionId int64) error
-	List(ctx context.Context) ([]*model.Subscription, error)
-	Total(ctx context.Context, subscription *model.Subscription) (int64, error)
+	Restore(ctx context.Context, subscriptionId int64) error
+	List(ctx context.Context, filter *model.Filter) ([]*model.Subscription, error)
+	Total(ctx context.Context, subscription *model.Subscription, filter *model.Filter) (int64, error)
+	Totals(ctx context.Context, subscriptions []*model.Subscription) ([]int64, error)
+	History(ctx context.Context, subscriptionId int64, filter *model.AuditFilter) ([]*model.AuditRecord, error)
+	Pause(ctx context.Context, subscription *model.Subscription, from time.Time) error
+	Resume(ctx context.Context, subscription *model.Subscription, at time.Time) error
+	Cancel(ctx context.Context, subscription *model.Subscription, endDate time.Time) error
+	AddDiscount(ctx context.Context, subscription *model.Subscription, discount *model.Discount) error
+	RemoveDiscount(ctx context.Context, subscription *model.Subscription, discountId int64) error
+	Discounts(ctx context.Context, subscriptionId int64) ([]*model.Discount, error)
+	EffectivePrices(ctx context.Context, subscriptionId int64) ([]*model.PriceChange, error)
+	AddMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error
+	RemoveMember(ctx context.Context, subscription *model.Subscription, member *model.Member) error
+	Members(ctx context.Context, subscriptionId int64) ([]*model.Member, error)
+	SchedulePrice(ctx context.Context, subscription *model.Subscription, price *model.ScheduledPrice) error
+	RemovePrice(ctx context.Context, subscription *model.Subscription, priceId int64) error
+	ScheduledPrices(ctx context.Context, subscriptionId int64) ([]*model.ScheduledPrice, error)
+}
+
+// lifecycle lists the actions allowed in each status. Pausing leads to paused, resuming to active and cancelling to
+// cancelled; ended subscriptions allow no action.
+var lifecycle = map[string][]string{
+	model.StatusActive: {model.ActionPause, model.ActionCancel},
+	model.StatusPaused: {model.ActionResume, model.ActionCancel},
+	model.StatusTrial:  {model.ActionCancel},
 }
 
 type SubscriptionService struct {
 	subscriptionRepository Subscription
+	policy                 *Policy
 }
 
-func New(subscriptionRepository Subscription) *SubscriptionService {
+func New(subscriptionRepository Subscription, policy *Policy) *SubscriptionService {
 	return &SubscriptionService{
 		subscriptionRepository: subscriptionRepository,
+		policy:                 policy,
 	}
 }
 
@@ -38,20 +65,39 @@ func (s *SubscriptionService) Create(ctx context.Context, data *model.ExternalDa
 		return err
 	}
 
-	if subscription.EndDate != nil && subscription.StartDate.After(*subscription.EndDate) {
-		return err_msg.LaterDate
+	if err := s.policy.Authorize(ctx, SubscriptionWrite, subscription.UserId); err != nil {
+		return err
 	}
 
+	if err := validateDates(subscription); err != nil {
+		return err
+	}
+	subscription.Status = initialStatus(subscription, time.Now())
+
 	return s.subscriptionRepository.Create(ctx, subscription)
 }
 
-func (s *SubscriptionService) Read(ctx context.Context, subscriptionId int64) (*model.ExternalData, error) {
-	read, err := s.subscriptionRepository.Read(ctx, subscriptionId)
+// Read returns the subscription with the schedule of its effective price, which is only known for the current
+// state of a live subscription.
+func (s *SubscriptionService) Read(ctx context.Context, subscriptionId int64, filter *model.Filter) (*model.SubscriptionDetail, error) {
+	if err := s.authorizeFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	read, err := s.readAuthorized(ctx, SubscriptionRead, subscriptionId, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	return mapOut(read), nil
+	detail := &model.SubscriptionDetail{ExternalData: *mapOut(read)}
+	if filter.AsOf == nil && read.DeletedAt == nil {
+		detail.EffectivePrices, err = s.subscriptionRepository.EffectivePrices(ctx, subscriptionId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return detail, nil
 }
 
 func (s *SubscriptionService) Update(ctx context.Context, subscriptionId int64, data *model.ExternalData) error {
@@ -61,15 +107,56 @@ func (s *SubscriptionService) Update(ctx context.Context, subscriptionId int64,
 	}
 	subscription.ID = subscriptionId
 
+	if err := s.authorizeOwner(ctx, SubscriptionWrite, subscriptionId); err != nil {
+		return err
+	}
+
+	if err := s.policy.Authorize(ctx, SubscriptionWrite, subscription.UserId); err != nil {
+		return err
+	}
+
+	if err := validateDates(subscription); err != nil {
+		return err
+	}
+	subscription.Status = initialStatus(subscription, time.Now())
+
 	return s.subscriptionRepository.Update(ctx, subscription)
 }
 
 func (s *SubscriptionService) Delete(ctx context.Context, subscriptionId int64) error {
+	if err := s.authorizeOwner(ctx, SubscriptionDelete, subscriptionId); err != nil {
+		return err
+	}
+
 	return s.subscriptionRepository.Delete(ctx, subscriptionId)
 }
 
-func (s *SubscriptionService) List(ctx context.Context) ([]*model.ExternalData, error) {
-	subscriptions, err := s.subscriptionRepository.List(ctx)
+func (s *SubscriptionService) Restore(ctx context.Context, subscriptionId int64) error {
+	if err := s.policy.Authorize(ctx, SubscriptionAdmin, ""); err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.Restore(ctx, subscriptionId)
+}
+
+func (s *SubscriptionService) List(ctx context.Context, filter *model.Filter) ([]*model.ExternalData, error) {
+	if err := s.authorizeFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	actor, scope, err := s.policy.scope(ctx, SubscriptionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == ScopeOwn {
+		if filter.UserId != "" && filter.UserId != actor.ID {
+			return nil, s.policy.Authorize(ctx, SubscriptionRead, filter.UserId)
+		}
+		filter.UserId = actor.ID
+	}
+
+	subscriptions, err := s.subscriptionRepository.List(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -82,17 +169,54 @@ func (s *SubscriptionService) List(ctx context.Context) ([]*model.ExternalData,
 	return list, nil
 }
 
-func (s *SubscriptionService) Total(ctx context.Context, data *model.ExternalData) (int64, error) {
-	subscription, err := mapIn(data)
+// ListOf lists the live subscriptions of each of the users in one query. errs[i] is set when the subscriptions of
+// userIds[i] cannot be listed, without failing the other users.
+func (s *SubscriptionService) ListOf(ctx context.Context, userIds []string) ([][]*model.ExternalData, []error) {
+	lists := make([][]*model.ExternalData, len(userIds))
+	errs := make([]error, len(userIds))
+
+	var allowed []string
+	for i, userId := range userIds {
+		if errs[i] = s.policy.Authorize(ctx, SubscriptionRead, userId); errs[i] == nil {
+			allowed = append(allowed, userId)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return lists, errs
+	}
+
+	subscriptions, err := s.subscriptionRepository.List(ctx, &model.Filter{UserIds: allowed})
 	if err != nil {
-		return 0, err
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return lists, errs
 	}
 
-	if subscription.EndDate != nil && subscription.StartDate.After(*subscription.EndDate) {
-		return 0, err_msg.LaterDate
+	byUser := make(map[string][]*model.ExternalData, len(allowed))
+	for _, subscription := range subscriptions {
+		byUser[subscription.UserId] = append(byUser[subscription.UserId], mapOut(subscription))
 	}
 
-	total, err := s.subscriptionRepository.Total(ctx, subscription)
+	for i, userId := range userIds {
+		if errs[i] == nil {
+			lists[i] = byUser[userId]
+		}
+	}
+
+	return lists, errs
+}
+
+func (s *SubscriptionService) Total(ctx context.Context, data *model.ExternalData, filter *model.Filter) (int64, error) {
+	subscription, err := s.totalOf(ctx, data, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := s.subscriptionRepository.Total(ctx, subscription, filter)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return 0, err
 	}
@@ -113,6 +237,440 @@ func (s *SubscriptionService) Total(ctx context.Context, data *model.ExternalDat
 	return total, nil
 }
 
+// Totals computes the totals of the current state of each of data, as Total does, in one query. errs[i] is set when
+// the total of data[i] cannot be computed, without failing the others.
+func (s *SubscriptionService) Totals(ctx context.Context, data []*model.ExternalData) ([]int64, []error) {
+	totals := make([]int64, len(data))
+	errs := make([]error, len(data))
+
+	var (
+		batch   []*model.Subscription
+		batched []int
+	)
+	for i := range data {
+		subscription, err := s.totalOf(ctx, data[i], &model.Filter{})
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		// Ranges without an end are computed from the rows themselves, one at a time.
+		if subscription.EndDate == nil {
+			totals[i], errs[i] = s.subscriptionRepository.Total(ctx, subscription, &model.Filter{})
+			continue
+		}
+
+		batch = append(batch, subscription)
+		batched = append(batched, i)
+	}
+
+	if len(batch) == 0 {
+		return totals, errs
+	}
+
+	batchTotals, err := s.subscriptionRepository.Totals(ctx, batch)
+	for j, i := range batched {
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		totals[i] = batchTotals[j]
+	}
+
+	return totals, errs
+}
+
+// totalOf checks that the caller may compute the total of data with the filter and returns the subscription range
+// of it. The total is of one service, the budgets total all services of a user themselves.
+func (s *SubscriptionService) totalOf(ctx context.Context, data *model.ExternalData, filter *model.Filter) (*model.Subscription, error) {
+	if data.ServiceName == "" {
+		return nil, fmt.Errorf("%w: service_name is required", err_msg.InvalidRequest)
+	}
+
+	subscription, err := mapIn(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.Authorize(ctx, ReportRead, subscription.UserId); err != nil {
+		return nil, err
+	}
+
+	if subscription.EndDate != nil && subscription.StartDate.After(*subscription.EndDate) {
+		return nil, err_msg.LaterDate
+	}
+
+	return subscription, nil
+}
+
+func (s *SubscriptionService) History(ctx context.Context, subscriptionId int64, filter *model.AuditFilter) ([]*model.AuditRecord, error) {
+	if err := s.authorizeOwner(ctx, SubscriptionRead, subscriptionId); err != nil {
+		return nil, err
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, err_msg.LaterDate
+	}
+
+	return s.subscriptionRepository.History(ctx, subscriptionId, filter)
+}
+
+// Pause stops billing the subscription from the next month, since the current one is already paid.
+func (s *SubscriptionService) Pause(ctx context.Context, subscriptionId int64) error {
+	subscription, now, err := s.transition(ctx, subscriptionId, model.ActionPause)
+	if err != nil {
+		return err
+	}
+
+	from := monthStart(now).AddDate(0, 1, 0)
+	if subscription.StartDate.After(from) {
+		from = subscription.StartDate
+	}
+
+	return s.subscriptionRepository.Pause(ctx, subscription, from)
+}
+
+// Resume bills the subscription again from the current month.
+func (s *SubscriptionService) Resume(ctx context.Context, subscriptionId int64) error {
+	subscription, now, err := s.transition(ctx, subscriptionId, model.ActionResume)
+	if err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.Resume(ctx, subscription, monthStart(now))
+}
+
+// Cancel ends the subscription at the start of the current month when effective is model.CancelNow, so that the
+// current month is not billed, or at the start of the next month when it is model.CancelPeriodEnd. Either way the
+// subscription stays cancelled rather than ended.
+func (s *SubscriptionService) Cancel(ctx context.Context, subscriptionId int64, effective string) error {
+	if effective != model.CancelNow && effective != model.CancelPeriodEnd {
+		return fmt.Errorf("%w: effective must be %q or %q", err_msg.InvalidCancel, model.CancelNow, model.CancelPeriodEnd)
+	}
+
+	subscription, now, err := s.transition(ctx, subscriptionId, model.ActionCancel)
+	if err != nil {
+		return err
+	}
+
+	endDate := monthStart(now)
+	if effective == model.CancelPeriodEnd {
+		endDate = endDate.AddDate(0, 1, 0)
+	}
+	if subscription.EndDate != nil && subscription.EndDate.Before(endDate) {
+		endDate = *subscription.EndDate
+	}
+	if subscription.StartDate.After(endDate) {
+		endDate = subscription.StartDate
+	}
+
+	return s.subscriptionRepository.Cancel(ctx, subscription, endDate)
+}
+
+// AddDiscount attaches the discount to the subscription. Its periods are the months from its start date; those of
+// them that are not billed, paused or in trial, stay free rather than being discounted.
+func (s *SubscriptionService) AddDiscount(ctx context.Context, subscriptionId int64, discount *model.Discount) error {
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDiscount(subscription, discount); err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.AddDiscount(ctx, subscription, discount)
+}
+
+func (s *SubscriptionService) RemoveDiscount(ctx context.Context, subscriptionId, discountId int64) error {
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.RemoveDiscount(ctx, subscription, discountId)
+}
+
+func (s *SubscriptionService) Discounts(ctx context.Context, subscriptionId int64) ([]*model.Discount, error) {
+	if err := s.authorizeOwner(ctx, SubscriptionRead, subscriptionId); err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepository.Discounts(ctx, subscriptionId)
+}
+
+// SchedulePrice projects the subscription at the price in the spending forecast from the start month of the price
+// until the next scheduled price. Totals, budgets and analytics keep the price of the subscription, and months that
+// are not billed stay free.
+func (s *SubscriptionService) SchedulePrice(ctx context.Context, subscriptionId int64, price *model.ScheduledPrice) error {
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePrice(subscription, price); err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.SchedulePrice(ctx, subscription, price)
+}
+
+func (s *SubscriptionService) RemovePrice(ctx context.Context, subscriptionId, priceId int64) error {
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.RemovePrice(ctx, subscription, priceId)
+}
+
+func (s *SubscriptionService) ScheduledPrices(ctx context.Context, subscriptionId int64) ([]*model.ScheduledPrice, error) {
+	if err := s.authorizeOwner(ctx, SubscriptionRead, subscriptionId); err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepository.ScheduledPrices(ctx, subscriptionId)
+}
+
+// AddMember shares the subscription with another user from the start month of the member. The member pays for it, so
+// only admins add members.
+func (s *SubscriptionService) AddMember(ctx context.Context, subscriptionId int64, member *model.Member) error {
+	if err := s.policy.Authorize(ctx, SubscriptionAdmin, ""); err != nil {
+		return err
+	}
+
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if err := validateMember(subscription, member); err != nil {
+		return err
+	}
+
+	return s.subscriptionRepository.AddMember(ctx, subscription, member)
+}
+
+// RemoveMember stops sharing the subscription with the member from month from, by default from the next month,
+// since the current one is already billed.
+func (s *SubscriptionService) RemoveMember(ctx context.Context, subscriptionId, memberId int64, from string) error {
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if from == "" {
+		from = monthStart(time.Now().UTC()).AddDate(0, 1, 0).Format("01-2006")
+	}
+	if _, err := time.Parse("01-2006", from); err != nil {
+		return fmt.Errorf("%w: from must be MM-YYYY", err_msg.InvalidMember)
+	}
+
+	return s.subscriptionRepository.RemoveMember(ctx, subscription, &model.Member{ID: memberId, EndDate: from})
+}
+
+func (s *SubscriptionService) Members(ctx context.Context, subscriptionId int64) ([]*model.Member, error) {
+	if err := s.authorizeOwner(ctx, SubscriptionRead, subscriptionId); err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepository.Members(ctx, subscriptionId)
+}
+
+// transition reads the subscription for a lifecycle action and checks that the actor may change it and that
+// its status allows the action.
+func (s *SubscriptionService) transition(ctx context.Context, subscriptionId int64, action string) (*model.Subscription, time.Time, error) {
+	now := time.Now().UTC()
+
+	subscription, err := s.writable(ctx, subscriptionId)
+	if err != nil {
+		return nil, now, err
+	}
+
+	current := status(subscription, now)
+	if !slices.Contains(lifecycle[current], action) {
+		return nil, now, fmt.Errorf("%w: cannot %s a subscription that is %s", err_msg.InvalidTransition, action, current)
+	}
+
+	return subscription, now, nil
+}
+
+// writable reads the live subscription and checks that the actor may change it.
+func (s *SubscriptionService) writable(ctx context.Context, subscriptionId int64) (*model.Subscription, error) {
+	subscription, err := s.readAuthorized(ctx, SubscriptionWrite, subscriptionId, &model.Filter{IncludeDeleted: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.DeletedAt != nil {
+		return nil, err_msg.NoRowsAffected
+	}
+
+	return subscription, nil
+}
+
+func (s *SubscriptionService) authorizeFilter(ctx context.Context, filter *model.Filter) error {
+	if filter.IncludeDeleted {
+		return s.policy.Authorize(ctx, SubscriptionAdmin, "")
+	}
+
+	return nil
+}
+
+func (s *SubscriptionService) authorizeOwner(ctx context.Context, permission Permission, subscriptionId int64) error {
+	_, err := s.readAuthorized(ctx, permission, subscriptionId, &model.Filter{IncludeDeleted: true})
+	return err
+}
+
+// readAuthorized reads a subscription the actor has permission on. A subscription of another user is reported as
+// missing, like one that does not exist, so that the status of the response does not reveal which ids exist.
+func (s *SubscriptionService) readAuthorized(ctx context.Context, permission Permission, subscriptionId int64, filter *model.Filter) (*model.Subscription, error) {
+	if err := s.policy.Allow(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.subscriptionRepository.Read(ctx, subscriptionId, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.Authorize(ctx, permission, subscription.UserId); err != nil {
+		if errors.Is(err, err_msg.Forbidden) {
+			return nil, err_msg.NoRowsAffected
+		}
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// status is the status of the subscription at now: cancelled once it has been cancelled, even when its end date has
+// passed, ended once its end date has passed, active once its trial has ended even if the conversion has not been
+// recorded yet, and the stored one otherwise.
+func status(subscription *model.Subscription, now time.Time) string {
+	if subscription.Status == model.StatusCancelled {
+		return model.StatusCancelled
+	}
+
+	if subscription.EndDate != nil && !subscription.EndDate.After(now) {
+		return model.StatusEnded
+	}
+
+	if subscription.Status == model.StatusTrial && !trialing(subscription, now) {
+		return model.StatusActive
+	}
+
+	return subscription.Status
+}
+
+// initialStatus is the status of a subscription written at now, unless it has been paused or cancelled.
+func initialStatus(subscription *model.Subscription, now time.Time) string {
+	if trialing(subscription, now) {
+		return model.StatusTrial
+	}
+
+	return model.StatusActive
+}
+
+func trialing(subscription *model.Subscription, now time.Time) bool {
+	return subscription.TrialEndDate != nil && subscription.TrialEndDate.After(now)
+}
+
+func validateDates(subscription *model.Subscription) error {
+	if subscription.EndDate != nil && subscription.StartDate.After(*subscription.EndDate) {
+		return err_msg.LaterDate
+	}
+
+	if trial := subscription.TrialEndDate; trial != nil {
+		if subscription.StartDate.After(*trial) || (subscription.EndDate != nil && trial.After(*subscription.EndDate)) {
+			return err_msg.InvalidTrial
+		}
+	}
+
+	return nil
+}
+
+func validateDiscount(subscription *model.Subscription, discount *model.Discount) error {
+	switch discount.Kind {
+	case model.DiscountPercent:
+		if discount.Value < 1 || discount.Value > 100 {
+			return fmt.Errorf("%w: percent must be between 1 and 100", err_msg.InvalidDiscount)
+		}
+	case model.DiscountFixed:
+		if discount.Value < 1 {
+			return fmt.Errorf("%w: value must be positive", err_msg.InvalidDiscount)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be %s or %s", err_msg.InvalidDiscount, model.DiscountPercent, model.DiscountFixed)
+	}
+
+	if discount.Periods < 1 || discount.Periods > model.MaxDiscountPeriods {
+		return fmt.Errorf("%w: periods must be between 1 and %d", err_msg.InvalidDiscount, model.MaxDiscountPeriods)
+	}
+
+	start, err := time.Parse("01-2006", discount.StartDate)
+	if err != nil {
+		return fmt.Errorf("%w: start_date must be MM-YYYY", err_msg.InvalidDiscount)
+	}
+
+	if start.Before(monthStart(subscription.StartDate)) ||
+		(subscription.EndDate != nil && !start.Before(*subscription.EndDate)) {
+		return fmt.Errorf("%w: start_date must be between the StartDate and the EndDate of the subscription",
+			err_msg.InvalidDiscount)
+	}
+
+	return nil
+}
+
+// validatePrice accepts a price from a month after the start month of the subscription, since the price of the
+// subscription itself is billed from then, and before its end.
+func validatePrice(subscription *model.Subscription, price *model.ScheduledPrice) error {
+	if price.Price < 0 {
+		return fmt.Errorf("%w: price must not be negative", err_msg.InvalidPrice)
+	}
+
+	start, err := time.Parse("01-2006", price.StartDate)
+	if err != nil {
+		return fmt.Errorf("%w: start_date must be MM-YYYY", err_msg.InvalidPrice)
+	}
+
+	if !start.After(monthStart(subscription.StartDate)) ||
+		(subscription.EndDate != nil && !start.Before(*subscription.EndDate)) {
+		return fmt.Errorf("%w: start_date must be after the StartDate and before the EndDate of the subscription",
+			err_msg.InvalidPrice)
+	}
+
+	return nil
+}
+
+func validateMember(subscription *model.Subscription, member *model.Member) error {
+	if member.UserId == "" {
+		return fmt.Errorf("%w: user_id is required", err_msg.InvalidMember)
+	}
+
+	if member.UserId == subscription.UserId {
+		return fmt.Errorf("%w: the owner already pays the rest of the price", err_msg.InvalidMember)
+	}
+
+	if member.Share != nil && (*member.Share < 1 || *member.Share > 100) {
+		return fmt.Errorf("%w: share must be between 1 and 100", err_msg.InvalidMember)
+	}
+
+	if _, err := time.Parse("01-2006", member.StartDate); err != nil {
+		return fmt.Errorf("%w: start_date must be MM-YYYY", err_msg.InvalidMember)
+	}
+
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
 func mapIn(data *model.ExternalData) (*model.Subscription, error) {
 	var err error
 	layout := "02-01-2006"
@@ -135,6 +693,14 @@ func mapIn(data *model.ExternalData) (*model.Subscription, error) {
 		}
 	}
 
+	if data.TrialEndDate != "" {
+		subscription.TrialEndDate = new(time.Time)
+		*subscription.TrialEndDate, err = time.Parse(layout, fmt.Sprintf("01-%s", data.TrialEndDate))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return subscription, nil
 }
 
@@ -148,10 +714,19 @@ func mapOut(subscription *model.Subscription) *model.ExternalData {
 	}
 
 	data.StartDate = subscription.StartDate.Format(layout)
+	data.Status = status(subscription, time.Now())
 
 	if subscription.EndDate != nil {
 		data.EndDate = subscription.EndDate.Format(layout)
 	}
 
+	if subscription.TrialEndDate != nil {
+		data.TrialEndDate = subscription.TrialEndDate.Format(layout)
+	}
+
+	if subscription.DeletedAt != nil {
+		data.DeletedAt = subscription.DeletedAt.Format(time.RFC3339)
+	}
+
 	return data
 }
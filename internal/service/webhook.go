@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/oatsmoke/20250905/internal/lib/err_msg"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+var webhookEvents = []string{
+	model.EventSubscriptionCreated,
+	model.EventSubscriptionUpdated,
+	model.EventSubscriptionDeleted,
+	model.EventSubscriptionEnded,
+	model.EventSubscriptionPriceChanged,
+	model.EventSubscriptionPaused,
+	model.EventSubscriptionResumed,
+	model.EventSubscriptionCancelled,
+	model.EventTrialConverted,
+	model.EventDiscountAdded,
+	model.EventDiscountRemoved,
+	model.EventMemberAdded,
+	model.EventMemberRemoved,
+	model.EventPriceScheduled,
+	model.EventPriceRemoved,
+	model.EventBudgetThresholdCrossed,
+	model.EventAll,
+}
+
+type Webhook interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	Read(ctx context.Context, webhookId int64) (*model.Webhook, error)
+	Update(ctx context.Context, webhook *model.Webhook) error
+	Delete(ctx context.Context, webhookId int64) error
+	List(ctx context.Context) ([]*model.Webhook, error)
+	Deliveries(ctx context.Context, webhookId int64, status string) ([]*model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookId, deliveryId int64) error
+}
+
+type WebhookService struct {
+	webhookRepository Webhook
+	policy            *Policy
+}
+
+func NewWebhookService(webhookRepository Webhook, policy *Policy) *WebhookService {
+	return &WebhookService{
+		webhookRepository: webhookRepository,
+		policy:            policy,
+	}
+}
+
+func (s *WebhookService) Create(ctx context.Context, webhook *model.Webhook) error {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return err
+	}
+
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	return s.webhookRepository.Create(ctx, webhook)
+}
+
+func (s *WebhookService) Read(ctx context.Context, webhookId int64) (*model.Webhook, error) {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepository.Read(ctx, webhookId)
+}
+
+func (s *WebhookService) Update(ctx context.Context, webhookId int64, webhook *model.Webhook) error {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return err
+	}
+
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	webhook.ID = webhookId
+
+	return s.webhookRepository.Update(ctx, webhook)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, webhookId int64) error {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return err
+	}
+
+	return s.webhookRepository.Delete(ctx, webhookId)
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]*model.Webhook, error) {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepository.List(ctx)
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, webhookId int64, status string) ([]*model.WebhookDelivery, error) {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return nil, err
+	}
+
+	if status != "" && status != model.DeliveryPending && status != model.DeliveryDelivered && status != model.DeliveryDead {
+		return nil, fmt.Errorf("%w: unknown status %q", err_msg.InvalidWebhook, status)
+	}
+
+	return s.webhookRepository.Deliveries(ctx, webhookId, status)
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, webhookId, deliveryId int64) error {
+	if err := s.policy.Authorize(ctx, WebhookManage, ""); err != nil {
+		return err
+	}
+
+	return s.webhookRepository.Redeliver(ctx, webhookId, deliveryId)
+}
+
+func validateWebhook(webhook *model.Webhook) error {
+	endpoint, err := url.Parse(webhook.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", err_msg.InvalidWebhook)
+	}
+
+	if len(webhook.Events) == 0 {
+		return fmt.Errorf("%w: events are empty", err_msg.InvalidWebhook)
+	}
+
+	for _, event := range webhook.Events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("%w: unknown event %q", err_msg.InvalidWebhook, event)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+type BudgetEvaluator interface {
+	Evaluate(ctx context.Context, now time.Time) (int, error)
+}
+
+type BudgetWorker struct {
+	evaluator BudgetEvaluator
+	interval  time.Duration
+}
+
+func NewBudgetWorker(evaluator BudgetEvaluator, interval time.Duration) *BudgetWorker {
+	return &BudgetWorker{
+		evaluator: evaluator,
+		interval:  interval,
+	}
+}
+
+func (w *BudgetWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.evaluator.Evaluate(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
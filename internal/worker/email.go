@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/oatsmoke/20250905/internal/notifier"
+)
+
+const emailBatchSize = 50
+
+type EmailSender interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*model.Email, error)
+	MarkSent(ctx context.Context, emailId int64) error
+	MarkFailed(ctx context.Context, emailId int64, lastError string, nextAttemptAt *time.Time) error
+}
+
+type EmailWorker struct {
+	emails      EmailSender
+	smtp        notifier.Notifier
+	interval    time.Duration
+	maxAttempts int
+	timeout     time.Duration
+	lease       time.Duration
+}
+
+// NewEmailWorker gives every email timeout to be sent. The emails of a batch are leased for as long as sending all of
+// them may take, so that another worker does not claim an email that is still being sent.
+func NewEmailWorker(emails EmailSender, smtp notifier.Notifier, interval time.Duration, maxAttempts int, timeout time.Duration) *EmailWorker {
+	return &EmailWorker{
+		emails:      emails,
+		smtp:        smtp,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		timeout:     timeout,
+		lease:       emailBatchSize*timeout + time.Minute,
+	}
+}
+
+func (w *EmailWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.SendQueued(ctx); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendQueued renders and sends one batch of queued emails and records the outcome of every attempt.
+func (w *EmailWorker) SendQueued(ctx context.Context) error {
+	emails, err := w.emails.Claim(ctx, emailBatchSize, w.lease)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range emails {
+		sendCtx, cancel := context.WithTimeout(ctx, w.timeout)
+		err := w.send(sendCtx, email)
+		cancel()
+		if err != nil {
+			var nextAttemptAt *time.Time
+			if email.Attempts+1 < w.maxAttempts {
+				next := time.Now().Add(backoff(email.Attempts))
+				nextAttemptAt = &next
+			}
+
+			if err := w.emails.MarkFailed(ctx, email.ID, err.Error(), nextAttemptAt); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := w.emails.MarkSent(ctx, email.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *EmailWorker) send(ctx context.Context, email *model.Email) error {
+	notification := &notifier.Notification{
+		UserId:  email.UserId,
+		Kind:    email.Kind,
+		Channel: model.ChannelEmail,
+		Address: email.Address,
+		Locale:  email.Locale,
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(email.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&notification.Data); err != nil {
+		return fmt.Errorf("decode %s email data: %w", email.Kind, err)
+	}
+
+	if err := notifier.Render(notification); err != nil {
+		return err
+	}
+
+	return w.smtp.Notify(ctx, notification)
+}
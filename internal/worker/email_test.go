@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/oatsmoke/20250905/internal/notifier"
+)
+
+// fakeSender keeps queued emails in memory and records the lease they were claimed with and the outcome of every
+// attempt.
+type fakeSender struct {
+	emails []*model.Email
+	lease  time.Duration
+	sent   []int64
+	failed []int64
+}
+
+func (s *fakeSender) Claim(_ context.Context, limit int, lease time.Duration) ([]*model.Email, error) {
+	s.lease = lease
+	return s.emails[:min(limit, len(s.emails))], nil
+}
+
+func (s *fakeSender) MarkSent(_ context.Context, emailId int64) error {
+	s.sent = append(s.sent, emailId)
+	return nil
+}
+
+func (s *fakeSender) MarkFailed(_ context.Context, emailId int64, _ string, _ *time.Time) error {
+	s.failed = append(s.failed, emailId)
+	return nil
+}
+
+// hungServer accepts SMTP connections and never answers them.
+func hungServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestEmailWorkerTimesOutEverySend(t *testing.T) {
+	smtp, err := notifier.NewSMTP(hungServer(t), "", "", "subscriptions@localhost", notifier.TLSNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &fakeSender{emails: []*model.Email{
+		{ID: 1, UserId: "alice", Kind: "end", Locale: "en", Address: "alice@example.com", Data: []byte(`{}`)},
+		{ID: 2, UserId: "bob", Kind: "end", Locale: "en", Address: "bob@example.com", Data: []byte(`{}`)},
+	}}
+	timeout := 100 * time.Millisecond
+	w := NewEmailWorker(sender, smtp, time.Minute, 5, timeout)
+
+	start := time.Now()
+	if err := w.SendQueued(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*timeout {
+		t.Errorf("sending took %s, want every email to give up after %s", elapsed, timeout)
+	}
+	if len(sender.failed) != 2 || len(sender.sent) != 0 {
+		t.Errorf("got %d failed and %d sent, want both failed", len(sender.failed), len(sender.sent))
+	}
+	if batch := emailBatchSize * timeout; sender.lease <= batch {
+		t.Errorf("got lease %s, want more than the %s a batch may take", sender.lease, batch)
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type SubscriptionEnder interface {
+	EndSubscriptions(ctx context.Context, now time.Time) ([]*model.Subscription, error)
+}
+
+// EndingWorker announces the subscriptions that reach their end date, which no request does.
+type EndingWorker struct {
+	ender    SubscriptionEnder
+	interval time.Duration
+}
+
+func NewEndingWorker(ender SubscriptionEnder, interval time.Duration) *EndingWorker {
+	return &EndingWorker{
+		ender:    ender,
+		interval: interval,
+	}
+}
+
+func (w *EndingWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.ender.EndSubscriptions(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type Purger interface {
+	Purge(ctx context.Context, deletedBefore time.Time) ([]*model.Subscription, error)
+}
+
+type PurgeWorker struct {
+	purger    Purger
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurgeWorker(purger Purger, retention, interval time.Duration) *PurgeWorker {
+	return &PurgeWorker{
+		purger:    purger,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+func (w *PurgeWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.purger.Purge(ctx, time.Now().Add(-w.retention)); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
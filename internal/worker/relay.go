@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/events"
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+const (
+	relayBatchSize      = 100
+	relayPublishTimeout = 5 * time.Second
+	// relayLease outlasts the publishing of a whole batch, so that a slow batch is not claimed again by another relay.
+	relayLease = relayBatchSize*relayPublishTimeout + time.Minute
+)
+
+type Outbox interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*events.Envelope, error)
+	MarkPublished(ctx context.Context, eventId int64) error
+	Release(ctx context.Context, eventIds []int64) error
+}
+
+type RelayWorker struct {
+	outbox    Outbox
+	publisher events.Publisher
+	interval  time.Duration
+}
+
+func NewRelayWorker(outbox Outbox, publisher events.Publisher, interval time.Duration) *RelayWorker {
+	return &RelayWorker{
+		outbox:    outbox,
+		publisher: publisher,
+		interval:  interval,
+	}
+}
+
+func (w *RelayWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			published, err := w.Relay(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.Error(err)
+			}
+
+			if published < relayBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publishes one batch of claimed events in outbox order and marks them published. The broker is called outside
+// any transaction. A failed event holds back the later events of its ordering key until the next run, so delivery is
+// at-least-once and ordered per subscription and per budget.
+func (w *RelayWorker) Relay(ctx context.Context) (int, error) {
+	envelopes, err := w.outbox.Claim(ctx, relayBatchSize, relayLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		published int
+		released  []int64
+	)
+	failed := make(map[string]bool)
+	for _, envelope := range envelopes {
+		if failed[envelope.Key] {
+			released = append(released, envelope.ID)
+			continue
+		}
+
+		if err := w.publish(ctx, envelope); err != nil {
+			logger.Error(fmt.Errorf("publish event with id %d: %w", envelope.ID, err))
+			failed[envelope.Key] = true
+			released = append(released, envelope.ID)
+			continue
+		}
+
+		if err := w.outbox.MarkPublished(ctx, envelope.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	if len(released) > 0 {
+		if err := w.outbox.Release(ctx, released); err != nil {
+			return published, err
+		}
+	}
+
+	if published > 0 {
+		logger.Info(fmt.Sprintf("%d events published", published))
+	}
+	return published, nil
+}
+
+func (w *RelayWorker) publish(ctx context.Context, envelope *events.Envelope) error {
+	ctx, cancel := context.WithTimeout(ctx, relayPublishTimeout)
+	defer cancel()
+
+	return w.publisher.Publish(ctx, envelope)
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+	"github.com/oatsmoke/20250905/internal/notifier"
+)
+
+type Reminders interface {
+	Due(ctx context.Context, now time.Time, renewalWindow, endWindow time.Duration) ([]*model.Reminder, error)
+	Claim(ctx context.Context, reminder *model.Reminder) (bool, error)
+	Release(ctx context.Context, reminder *model.Reminder) error
+}
+
+type ReminderWorker struct {
+	reminders     Reminders
+	notifier      notifier.Notifier
+	interval      time.Duration
+	renewalWindow time.Duration
+	endWindow     time.Duration
+}
+
+func NewReminderWorker(reminders Reminders, notifier notifier.Notifier, interval, renewalWindow, endWindow time.Duration) *ReminderWorker {
+	return &ReminderWorker{
+		reminders:     reminders,
+		notifier:      notifier,
+		interval:      interval,
+		renewalWindow: renewalWindow,
+		endWindow:     endWindow,
+	}
+}
+
+func (w *ReminderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.SendDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends every reminder due at now once: a reminder is claimed before sending and released if sending fails.
+func (w *ReminderWorker) SendDue(ctx context.Context, now time.Time) error {
+	reminders, err := w.reminders.Due(ctx, now, w.renewalWindow, w.endWindow)
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		claimed, err := w.reminders.Claim(ctx, reminder)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		notification := &notifier.Notification{
+			UserId:  reminder.UserId,
+			Kind:    reminder.Kind,
+			Channel: reminder.Channel,
+			Address: reminder.Address,
+			Locale:  reminder.Locale,
+			Data: map[string]any{
+				"subscription_id": reminder.SubscriptionId,
+				"service_name":    reminder.ServiceName,
+				"price":           reminder.Price,
+				"due_date":        reminder.DueDate.Format(time.DateOnly),
+			},
+		}
+
+		err = notifier.Render(notification)
+		if err == nil {
+			err = w.notifier.Notify(ctx, notification)
+		}
+
+		if err != nil {
+			logger.Error(fmt.Errorf("%s reminder of subscription with id %d: %w", reminder.Kind, reminder.SubscriptionId, err))
+			if err := w.reminders.Release(ctx, reminder); err != nil {
+				return err
+			}
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("%s reminder of subscription with id %d sent", reminder.Kind, reminder.SubscriptionId))
+	}
+
+	return nil
+}
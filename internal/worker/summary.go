@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+)
+
+type Summarizer interface {
+	EnqueueMonthlySummaries(ctx context.Context, month time.Time) (int64, error)
+}
+
+type SummaryWorker struct {
+	summarizer Summarizer
+	interval   time.Duration
+}
+
+func NewSummaryWorker(summarizer Summarizer, interval time.Duration) *SummaryWorker {
+	return &SummaryWorker{
+		summarizer: summarizer,
+		interval:   interval,
+	}
+}
+
+// Run queues the summary of the previous month; queuing is idempotent, so every tick may try again.
+func (w *SummaryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+		month := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := w.summarizer.EnqueueMonthlySummaries(ctx, month); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+type TrialConverter interface {
+	ConvertTrials(ctx context.Context, now time.Time) ([]*model.Subscription, error)
+}
+
+type TrialWorker struct {
+	converter TrialConverter
+	interval  time.Duration
+}
+
+func NewTrialWorker(converter TrialConverter, interval time.Duration) *TrialWorker {
+	return &TrialWorker{
+		converter: converter,
+		interval:  interval,
+	}
+}
+
+func (w *TrialWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.converter.ConvertTrials(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/logger"
+	"github.com/oatsmoke/20250905/internal/lib/signature"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+const (
+	webhookBatchSize   = 50
+	webhookBaseBackoff = 10 * time.Second
+	webhookMaxBackoff  = time.Hour
+)
+
+type WebhookDeliverer interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, deliveryId int64) error
+	MarkFailed(ctx context.Context, deliveryId int64, lastError string, nextAttemptAt *time.Time) error
+}
+
+type WebhookWorker struct {
+	deliverer   WebhookDeliverer
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+	lease       time.Duration
+}
+
+// NewWebhookWorker expects a client with a timeout: the deliveries of a batch are leased for as long as sending all of
+// them may take, so that another worker does not claim a delivery that is still being sent.
+func NewWebhookWorker(deliverer WebhookDeliverer, client *http.Client, interval time.Duration, maxAttempts int) *WebhookWorker {
+	return &WebhookWorker{
+		deliverer:   deliverer,
+		client:      client,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		lease:       webhookBatchSize*client.Timeout + time.Minute,
+	}
+}
+
+func (w *WebhookWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends one batch of due deliveries and records the outcome of every attempt.
+func (w *WebhookWorker) DeliverDue(ctx context.Context) error {
+	deliveries, err := w.deliverer.Claim(ctx, webhookBatchSize, w.lease)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := w.send(ctx, delivery); err != nil {
+			var nextAttemptAt *time.Time
+			if delivery.Attempts+1 < w.maxAttempts {
+				next := time.Now().Add(backoff(delivery.Attempts))
+				nextAttemptAt = &next
+			}
+
+			if err := w.deliverer.MarkFailed(ctx, delivery.ID, err.Error(), nextAttemptAt); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := w.deliverer.MarkDelivered(ctx, delivery.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WebhookWorker) send(ctx context.Context, delivery *model.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(signature.TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(signature.Header, signature.Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func backoff(attempts int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 0; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, webhookMaxBackoff)
+}
@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oatsmoke/20250905/internal/lib/signature"
+	"github.com/oatsmoke/20250905/internal/model"
+)
+
+// fakeDeliverer keeps deliveries in memory. Every pending delivery is due, whatever its next attempt time, so that a
+// test retries by delivering again.
+type fakeDeliverer struct {
+	deliveries   []*model.WebhookDelivery
+	nextAttempts []*time.Time
+	lease        time.Duration
+	deliveredIds []int64
+	deadLettered []int64
+}
+
+func (d *fakeDeliverer) Claim(_ context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	d.lease = lease
+
+	var claimed []*model.WebhookDelivery
+	for _, delivery := range d.deliveries {
+		if delivery.Status == model.DeliveryPending && len(claimed) < limit {
+			claimed = append(claimed, delivery)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (d *fakeDeliverer) MarkDelivered(_ context.Context, deliveryId int64) error {
+	delivery := d.find(deliveryId)
+	delivery.Status = model.DeliveryDelivered
+	delivery.Attempts++
+	return nil
+}
+
+func (d *fakeDeliverer) MarkFailed(_ context.Context, deliveryId int64, lastError string, nextAttemptAt *time.Time) error {
+	delivery := d.find(deliveryId)
+	delivery.Attempts++
+	delivery.LastError = lastError
+	d.nextAttempts = append(d.nextAttempts, nextAttemptAt)
+	if nextAttemptAt == nil {
+		delivery.Status = model.DeliveryDead
+		d.deadLettered = append(d.deadLettered, deliveryId)
+	}
+	return nil
+}
+
+func (d *fakeDeliverer) find(deliveryId int64) *model.WebhookDelivery {
+	for _, delivery := range d.deliveries {
+		if delivery.ID == deliveryId {
+			return delivery
+		}
+	}
+
+	panic("unknown delivery " + strconv.FormatInt(deliveryId, 10))
+}
+
+// receiver answers 500 to the first failures requests and 200 afterwards, recording whether each request was signed
+// with the secret.
+type receiver struct {
+	secret   string
+	failures int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	signed   []bool
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(signature.TimestampHeader), 10, 64)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.signed = append(rc.signed, signature.Verify(rc.secret, time.Unix(timestamp, 0), body, r.Header.Get(signature.Header)))
+
+	if len(rc.requests) <= rc.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newDelivery(url, secret string) *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		ID:        7,
+		WebhookId: 3,
+		Event:     model.EventSubscriptionCreated,
+		Payload:   []byte(`{"event":"subscription.created"}`),
+		Status:    model.DeliveryPending,
+		URL:       url,
+		Secret:    secret,
+	}
+}
+
+func TestWebhookWorkerSignsAndRetriesWithBackoff(t *testing.T) {
+	rc := &receiver{secret: "s3cret", failures: 2}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	deliverer := &fakeDeliverer{deliveries: []*model.WebhookDelivery{newDelivery(server.URL, "s3cret")}}
+	w := NewWebhookWorker(deliverer, &http.Client{Timeout: time.Second}, time.Minute, 5)
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if err := w.DeliverDue(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if i < 2 {
+			next := deliverer.nextAttempts[i]
+			if next == nil {
+				t.Fatalf("attempt %d: dead-lettered, want a retry", i+1)
+			}
+			if want := backoff(i); next.Before(start.Add(want)) || next.After(time.Now().Add(want)) {
+				t.Errorf("attempt %d: next attempt in %s, want %s", i+1, next.Sub(start), want)
+			}
+		}
+	}
+
+	if len(rc.requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(rc.requests))
+	}
+	for i, r := range rc.requests {
+		if !rc.signed[i] {
+			t.Errorf("request %d: signature does not verify", i+1)
+		}
+		if got := r.Header.Get("X-Webhook-Delivery"); got != "7" {
+			t.Errorf("request %d: got delivery id %q, want 7", i+1, got)
+		}
+		if got := r.Header.Get("X-Webhook-Event"); got != model.EventSubscriptionCreated {
+			t.Errorf("request %d: got event %q, want %s", i+1, got, model.EventSubscriptionCreated)
+		}
+	}
+
+	delivery := deliverer.deliveries[0]
+	if delivery.Status != model.DeliveryDelivered || delivery.Attempts != 3 {
+		t.Errorf("got status %s after %d attempts, want delivered after 3", delivery.Status, delivery.Attempts)
+	}
+
+	if batch := webhookBatchSize * time.Second; deliverer.lease <= batch {
+		t.Errorf("got lease %s, want more than the %s a batch may take", deliverer.lease, batch)
+	}
+}
+
+func TestWebhookWorkerDeadLettersAfterMaxAttempts(t *testing.T) {
+	rc := &receiver{secret: "s3cret", failures: 10}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	deliverer := &fakeDeliverer{deliveries: []*model.WebhookDelivery{newDelivery(server.URL, "s3cret")}}
+	w := NewWebhookWorker(deliverer, &http.Client{Timeout: time.Second}, time.Minute, 2)
+
+	for i := 0; i < 3; i++ {
+		if err := w.DeliverDue(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(rc.requests) != 2 {
+		t.Errorf("got %d requests, want 2", len(rc.requests))
+	}
+	if len(deliverer.deadLettered) != 1 {
+		t.Fatalf("got %d dead-lettered deliveries, want 1", len(deliverer.deadLettered))
+	}
+	if delivery := deliverer.deliveries[0]; delivery.LastError == "" {
+		t.Error("got no last error on the dead-lettered delivery")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		0:  10 * time.Second,
+		1:  20 * time.Second,
+		2:  40 * time.Second,
+		8:  2560 * time.Second,
+		9:  time.Hour,
+		50: time.Hour,
+	} {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
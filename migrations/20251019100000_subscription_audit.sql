@@ -0,0 +1,22 @@
+-- Create "subscription_audit" table
+CREATE TABLE "subscription_audit" (
+  "id" bigserial NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "actor" character varying(50) NOT NULL,
+  "action" character varying(20) NOT NULL,
+  "before" jsonb NULL,
+  "after" jsonb NULL,
+  "request_id" character varying(64) NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_subscription_audit_subscription_created" to table: "subscription_audit"
+CREATE INDEX "idx_subscription_audit_subscription_created" ON "subscription_audit" ("subscription_id", "created_at");
+-- Create "subscription_audit_append_only" function
+CREATE FUNCTION "subscription_audit_append_only" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    raise exception 'subscription_audit is append-only';
+end;
+$$;
+-- Create trigger "subscription_audit_append_only"
+CREATE TRIGGER "subscription_audit_append_only" BEFORE UPDATE OR DELETE ON "subscription_audit" FOR EACH ROW EXECUTE FUNCTION "subscription_audit_append_only"();
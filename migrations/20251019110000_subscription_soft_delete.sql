@@ -0,0 +1,4 @@
+-- Modify "subscriptions" table
+ALTER TABLE "subscriptions" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "idx_subscriptions_deleted_at" to table: "subscriptions"
+CREATE INDEX "idx_subscriptions_deleted_at" ON "subscriptions" ("deleted_at") WHERE (deleted_at IS NOT NULL);
@@ -0,0 +1,43 @@
+-- Create "subscription_versions" table
+CREATE TABLE "subscription_versions" (
+  "version_id" bigserial NOT NULL,
+  "id" bigint NOT NULL,
+  "service_name" character varying(50) NOT NULL,
+  "price" bigint NOT NULL,
+  "user_id" character varying(50) NOT NULL,
+  "start_date" date NOT NULL,
+  "end_date" date NULL,
+  "deleted_at" timestamptz NULL,
+  "valid_from" timestamptz NOT NULL,
+  "valid_to" timestamptz NULL,
+  PRIMARY KEY ("version_id")
+);
+-- Create index "idx_subscription_versions_id_valid" to table: "subscription_versions"
+CREATE INDEX "idx_subscription_versions_id_valid" ON "subscription_versions" ("id", "valid_from", "valid_to");
+-- Create index "idx_subscription_versions_user_service_valid" to table: "subscription_versions"
+CREATE INDEX "idx_subscription_versions_user_service_valid" ON "subscription_versions" ("user_id", "service_name", "valid_from", "valid_to");
+-- Create "subscription_versions_track" function
+CREATE FUNCTION "subscription_versions_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        update subscription_versions
+        set valid_to = clock_timestamp()
+        where id = old.id
+          and valid_to is null;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') then
+        insert into subscription_versions (id, service_name, price, user_id, start_date, end_date, deleted_at, valid_from)
+        values (new.id, new.service_name, new.price, new.user_id, new.start_date, new.end_date, new.deleted_at,
+                clock_timestamp());
+    end if;
+
+    return null;
+end;
+$$;
+-- Create trigger "subscription_versions_track"
+CREATE TRIGGER "subscription_versions_track" AFTER INSERT OR UPDATE OR DELETE ON "subscriptions" FOR EACH ROW EXECUTE FUNCTION "subscription_versions_track"();
+-- Backfill current state, tracking starts from this migration
+INSERT INTO "subscription_versions" ("id", "service_name", "price", "user_id", "start_date", "end_date", "deleted_at", "valid_from")
+SELECT "id", "service_name", "price", "user_id", "start_date", "end_date", "deleted_at", now()
+FROM "subscriptions";
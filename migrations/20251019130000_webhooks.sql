@@ -0,0 +1,28 @@
+-- Create "webhooks" table
+CREATE TABLE "webhooks" (
+  "id" bigserial NOT NULL,
+  "url" text NOT NULL,
+  "events" text[] NOT NULL,
+  "secret" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id")
+);
+-- Create "webhook_deliveries" table
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial NOT NULL,
+  "webhook_id" bigint NOT NULL,
+  "event" character varying(50) NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+  "last_error" text NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "delivered_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "webhook_deliveries_webhook_id_fkey" FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_webhook_deliveries_pending" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_pending" ON "webhook_deliveries" ("next_attempt_at") WHERE ((status)::text = 'pending'::text);
+-- Create index "idx_webhook_deliveries_webhook" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_webhook" ON "webhook_deliveries" ("webhook_id", "id");
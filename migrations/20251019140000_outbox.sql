@@ -0,0 +1,12 @@
+-- Create "outbox" table
+CREATE TABLE "outbox" (
+  "id" bigserial NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "type" character varying(50) NOT NULL,
+  "payload" jsonb NOT NULL,
+  "occurred_at" timestamptz NOT NULL DEFAULT now(),
+  "published_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_outbox_unpublished" to table: "outbox"
+CREATE INDEX "idx_outbox_unpublished" ON "outbox" ("id") WHERE (published_at IS NULL);
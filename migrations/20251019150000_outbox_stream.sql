@@ -0,0 +1,8 @@
+-- Modify "outbox" table
+ALTER TABLE "outbox" ADD COLUMN "user_id" character varying(50) NOT NULL DEFAULT '', ADD COLUMN "service_name" character varying(50) NOT NULL DEFAULT '';
+-- Backfill "outbox" subject columns
+UPDATE "outbox"
+SET "user_id"      = coalesce("payload" #>> '{subscription,user_id}', "payload" #>> '{after,user_id}', ''),
+    "service_name" = coalesce("payload" #>> '{subscription,service_name}', "payload" #>> '{after,service_name}', '');
+-- Create index "idx_outbox_user_service" to table: "outbox"
+CREATE INDEX "idx_outbox_user_service" ON "outbox" ("user_id", "service_name", "id");
@@ -0,0 +1,18 @@
+-- Create "notification_preferences" table
+CREATE TABLE "notification_preferences" (
+  "user_id" character varying(50) NOT NULL,
+  "channel" character varying(20) NOT NULL DEFAULT 'log',
+  "address" text NOT NULL DEFAULT '',
+  "renewal_reminders" boolean NOT NULL DEFAULT true,
+  "end_reminders" boolean NOT NULL DEFAULT true,
+  PRIMARY KEY ("user_id")
+);
+-- Create "reminders" table
+CREATE TABLE "reminders" (
+  "subscription_id" bigint NOT NULL,
+  "kind" character varying(20) NOT NULL,
+  "due_date" date NOT NULL,
+  "channel" character varying(20) NOT NULL,
+  "sent_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("subscription_id", "kind", "due_date")
+);
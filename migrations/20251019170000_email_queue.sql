@@ -0,0 +1,22 @@
+-- Modify "notification_preferences" table
+ALTER TABLE "notification_preferences" ADD COLUMN "monthly_summaries" boolean NOT NULL DEFAULT true, ADD COLUMN "locale" character varying(10) NOT NULL DEFAULT 'en';
+-- Create "email_queue" table
+CREATE TABLE "email_queue" (
+  "id" bigserial NOT NULL,
+  "user_id" character varying(50) NOT NULL,
+  "kind" character varying(50) NOT NULL,
+  "locale" character varying(10) NOT NULL DEFAULT 'en',
+  "address" text NOT NULL,
+  "data" jsonb NOT NULL DEFAULT '{}',
+  "dedup_key" text NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+  "last_error" text NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "sent_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "email_queue_dedup_key_key" UNIQUE ("dedup_key")
+);
+-- Create index "idx_email_queue_pending" to table: "email_queue"
+CREATE INDEX "idx_email_queue_pending" ON "email_queue" ("next_attempt_at") WHERE ((status)::text = 'pending'::text);
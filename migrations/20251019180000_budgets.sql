@@ -0,0 +1,26 @@
+-- Create "budgets" table
+CREATE TABLE "budgets" (
+  "id" bigserial NOT NULL,
+  "user_id" character varying(50) NOT NULL,
+  "service_name" character varying(50) NOT NULL DEFAULT '',
+  "amount" bigint NOT NULL,
+  "currency" character(3) NOT NULL DEFAULT 'RUB',
+  "period" character varying(10) NOT NULL DEFAULT 'month',
+  "thresholds" integer[] NOT NULL DEFAULT '{80,100}',
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "budgets_amount_check" CHECK (amount > 0),
+  CONSTRAINT "budgets_period_check" CHECK ((period)::text = ANY ((ARRAY['month'::character varying, 'year'::character varying])::text[]))
+);
+-- Create index "idx_budgets_user" to table: "budgets"
+CREATE INDEX "idx_budgets_user" ON "budgets" ("user_id");
+-- Create "budget_alerts" table
+CREATE TABLE "budget_alerts" (
+  "budget_id" bigint NOT NULL,
+  "period_start" date NOT NULL,
+  "threshold" integer NOT NULL,
+  "spent" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("budget_id", "period_start", "threshold"),
+  CONSTRAINT "budget_alerts_budget_id_fkey" FOREIGN KEY ("budget_id") REFERENCES "budgets" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Create index "idx_subscriptions_active" to table: "subscriptions"
+CREATE INDEX "idx_subscriptions_active" ON "subscriptions" ("start_date", "end_date") WHERE (deleted_at IS NULL);
@@ -0,0 +1,63 @@
+-- Create "subscription_monthly_costs" table
+CREATE TABLE "subscription_monthly_costs" (
+  "user_id" character varying(50) NOT NULL,
+  "service_name" character varying(50) NOT NULL,
+  "month" date NOT NULL,
+  "delta" bigint NOT NULL,
+  PRIMARY KEY ("user_id", "service_name", "month")
+);
+-- Create "subscription_monthly_costs_add" function
+CREATE FUNCTION "subscription_monthly_costs_add" ("p_user_id" character varying, "p_service_name" character varying, "p_month" date, "p_delta" bigint) RETURNS void LANGUAGE plpgsql AS $$
+begin
+    insert into subscription_monthly_costs as c (user_id, service_name, month, delta)
+    values (p_user_id, p_service_name, p_month, p_delta)
+    on conflict (user_id, service_name, month) do update
+        set delta = c.delta + excluded.delta;
+
+    delete
+    from subscription_monthly_costs
+    where user_id = p_user_id
+      and service_name = p_service_name
+      and month = p_month
+      and delta = 0;
+end;
+$$;
+-- Create "subscription_monthly_costs_track" function
+CREATE FUNCTION "subscription_monthly_costs_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') and old.deleted_at is null then
+        perform subscription_monthly_costs_add(old.user_id, old.service_name,
+                                               date_trunc('month', old.start_date)::date, -old.price);
+        if old.end_date is not null then
+            perform subscription_monthly_costs_add(old.user_id, old.service_name,
+                                                   date_trunc('month', old.end_date)::date, old.price);
+        end if;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') and new.deleted_at is null then
+        perform subscription_monthly_costs_add(new.user_id, new.service_name,
+                                               date_trunc('month', new.start_date)::date, new.price);
+        if new.end_date is not null then
+            perform subscription_monthly_costs_add(new.user_id, new.service_name,
+                                                   date_trunc('month', new.end_date)::date, -new.price);
+        end if;
+    end if;
+
+    return null;
+end;
+$$;
+-- Create trigger "subscription_monthly_costs_track"
+CREATE TRIGGER "subscription_monthly_costs_track" AFTER INSERT OR UPDATE OR DELETE ON "subscriptions" FOR EACH ROW EXECUTE FUNCTION "subscription_monthly_costs_track"();
+-- Backfill the costs of the current subscriptions
+INSERT INTO "subscription_monthly_costs" ("user_id", "service_name", "month", "delta")
+SELECT "user_id", "service_name", "month", sum("delta")
+FROM (SELECT "user_id", "service_name", date_trunc('month', "start_date")::date AS "month", "price" AS "delta"
+      FROM "subscriptions"
+      WHERE "deleted_at" IS NULL
+      UNION ALL
+      SELECT "user_id", "service_name", date_trunc('month', "end_date")::date, -"price"
+      FROM "subscriptions"
+      WHERE "deleted_at" IS NULL
+        AND "end_date" IS NOT NULL) AS "change"
+GROUP BY "user_id", "service_name", "month"
+HAVING sum("delta") <> 0;
@@ -0,0 +1,4 @@
+-- Add schema "btree_gist" extension
+CREATE EXTENSION IF NOT EXISTS "btree_gist";
+-- Modify "subscriptions" table
+ALTER TABLE "subscriptions" ADD COLUMN "no_overlap" boolean NOT NULL DEFAULT false, ADD CONSTRAINT "subscriptions_no_overlap" EXCLUDE USING gist ("user_id" WITH =, "service_name" WITH =, (daterange(start_date, end_date)) WITH &&) WHERE ((deleted_at IS NULL) AND no_overlap);
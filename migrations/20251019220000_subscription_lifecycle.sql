@@ -0,0 +1,117 @@
+-- Modify "subscriptions" table
+ALTER TABLE "subscriptions" ADD COLUMN "status" character varying(20) NOT NULL DEFAULT 'active', ADD CONSTRAINT "subscriptions_status_check" CHECK ((status)::text = ANY ((ARRAY['active'::character varying, 'paused'::character varying, 'cancelled'::character varying, 'trial'::character varying])::text[]));
+-- Modify "subscription_versions" table
+ALTER TABLE "subscription_versions" ADD COLUMN "status" character varying(20) NOT NULL DEFAULT 'active';
+-- Create "subscription_pauses" table
+CREATE TABLE "subscription_pauses" (
+  "id" bigserial NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "start_month" date NOT NULL,
+  "end_month" date NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "resumed_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "subscription_pauses_subscription_id_fkey" FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_subscription_pauses_open" to table: "subscription_pauses"
+CREATE UNIQUE INDEX "idx_subscription_pauses_open" ON "subscription_pauses" ("subscription_id") WHERE (resumed_at IS NULL);
+-- Create index "idx_subscription_pauses_subscription" to table: "subscription_pauses"
+CREATE INDEX "idx_subscription_pauses_subscription" ON "subscription_pauses" ("subscription_id", "start_month");
+-- Modify "subscription_versions_track" function
+CREATE OR REPLACE FUNCTION "subscription_versions_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        update subscription_versions
+        set valid_to = clock_timestamp()
+        where id = old.id
+          and valid_to is null;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') then
+        insert into subscription_versions (id, service_name, price, user_id, start_date, end_date, deleted_at, status,
+                                           valid_from)
+        values (new.id, new.service_name, new.price, new.user_id, new.start_date, new.end_date, new.deleted_at,
+                new.status, clock_timestamp());
+    end if;
+
+    return null;
+end;
+$$;
+-- Create "subscription_monthly_costs_pause" function
+CREATE FUNCTION "subscription_monthly_costs_pause" ("p_subscription" "subscriptions", "p_start_month" date, "p_end_month" date, "p_sign" integer) RETURNS void LANGUAGE plpgsql AS $$
+declare
+    v_start date := greatest(p_start_month, date_trunc('month', p_subscription.start_date)::date);
+    v_end   date := least(p_end_month, date_trunc('month', p_subscription.end_date)::date);
+begin
+    if v_end is not null and v_end <= v_start then
+        return;
+    end if;
+
+    perform subscription_monthly_costs_add(p_subscription.user_id, p_subscription.service_name, v_start,
+                                           -p_sign * p_subscription.price);
+    if v_end is not null then
+        perform subscription_monthly_costs_add(p_subscription.user_id, p_subscription.service_name, v_end,
+                                               p_sign * p_subscription.price);
+    end if;
+end;
+$$;
+-- Modify "subscription_monthly_costs_track" function
+CREATE OR REPLACE FUNCTION "subscription_monthly_costs_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+declare
+    pause record;
+begin
+    if tg_op in ('UPDATE', 'DELETE') and old.deleted_at is null then
+        perform subscription_monthly_costs_add(old.user_id, old.service_name,
+                                               date_trunc('month', old.start_date)::date, -old.price);
+        if old.end_date is not null then
+            perform subscription_monthly_costs_add(old.user_id, old.service_name,
+                                                   date_trunc('month', old.end_date)::date, old.price);
+        end if;
+
+        for pause in select start_month, end_month from subscription_pauses where subscription_id = old.id
+            loop
+                perform subscription_monthly_costs_pause(old, pause.start_month, pause.end_month, -1);
+            end loop;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') and new.deleted_at is null then
+        perform subscription_monthly_costs_add(new.user_id, new.service_name,
+                                               date_trunc('month', new.start_date)::date, new.price);
+        if new.end_date is not null then
+            perform subscription_monthly_costs_add(new.user_id, new.service_name,
+                                                   date_trunc('month', new.end_date)::date, -new.price);
+        end if;
+
+        for pause in select start_month, end_month from subscription_pauses where subscription_id = new.id
+            loop
+                perform subscription_monthly_costs_pause(new, pause.start_month, pause.end_month, 1);
+            end loop;
+    end if;
+
+    return null;
+end;
+$$;
+-- Create "subscription_pauses_track" function
+CREATE FUNCTION "subscription_pauses_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+declare
+    subscription subscriptions;
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        select * into subscription from subscriptions where id = old.subscription_id and deleted_at is null;
+        if found then
+            perform subscription_monthly_costs_pause(subscription, old.start_month, old.end_month, -1);
+        end if;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') then
+        select * into subscription from subscriptions where id = new.subscription_id and deleted_at is null;
+        if found then
+            perform subscription_monthly_costs_pause(subscription, new.start_month, new.end_month, 1);
+        end if;
+    end if;
+
+    return null;
+end;
+$$;
+-- Create trigger "subscription_pauses_track"
+CREATE TRIGGER "subscription_pauses_track" AFTER INSERT OR UPDATE OR DELETE ON "subscription_pauses" FOR EACH ROW EXECUTE FUNCTION "subscription_pauses_track"();
@@ -0,0 +1,84 @@
+-- Modify "subscriptions" table
+ALTER TABLE "subscriptions" ADD COLUMN "trial_end_date" date NULL;
+-- Create index "idx_subscriptions_trial" to table: "subscriptions"
+CREATE INDEX "idx_subscriptions_trial" ON "subscriptions" ("trial_end_date") WHERE ((status)::text = 'trial'::text);
+-- Modify "subscription_versions" table
+ALTER TABLE "subscription_versions" ADD COLUMN "trial_end_date" date NULL;
+-- Modify "subscription_versions_track" function
+CREATE OR REPLACE FUNCTION "subscription_versions_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        update subscription_versions
+        set valid_to = clock_timestamp()
+        where id = old.id
+          and valid_to is null;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') then
+        insert into subscription_versions (id, service_name, price, user_id, start_date, end_date, deleted_at, status,
+                                           trial_end_date, valid_from)
+        values (new.id, new.service_name, new.price, new.user_id, new.start_date, new.end_date, new.deleted_at,
+                new.status, new.trial_end_date, clock_timestamp());
+    end if;
+
+    return null;
+end;
+$$;
+-- Modify "subscription_monthly_costs_pause" function
+CREATE OR REPLACE FUNCTION "subscription_monthly_costs_pause" ("p_subscription" "subscriptions", "p_start_month" date, "p_end_month" date, "p_sign" integer) RETURNS void LANGUAGE plpgsql AS $$
+declare
+    v_start date := greatest(p_start_month,
+                             date_trunc('month', greatest(p_subscription.start_date,
+                                                          p_subscription.trial_end_date))::date);
+    v_end   date := least(p_end_month, date_trunc('month', p_subscription.end_date)::date);
+begin
+    if v_end is not null and v_end <= v_start then
+        return;
+    end if;
+
+    perform subscription_monthly_costs_add(p_subscription.user_id, p_subscription.service_name, v_start,
+                                           -p_sign * p_subscription.price);
+    if v_end is not null then
+        perform subscription_monthly_costs_add(p_subscription.user_id, p_subscription.service_name, v_end,
+                                               p_sign * p_subscription.price);
+    end if;
+end;
+$$;
+-- Create "subscription_monthly_costs_apply" function
+CREATE FUNCTION "subscription_monthly_costs_apply" ("p_subscription" "subscriptions", "p_sign" integer) RETURNS void LANGUAGE plpgsql AS $$
+declare
+    v_start date := date_trunc('month', greatest(p_subscription.start_date, p_subscription.trial_end_date))::date;
+    v_end   date := date_trunc('month', p_subscription.end_date)::date;
+    pause   record;
+begin
+    if v_end is not null and v_end <= v_start then
+        return;
+    end if;
+
+    perform subscription_monthly_costs_add(p_subscription.user_id, p_subscription.service_name, v_start,
+                                           p_sign * p_subscription.price);
+    if v_end is not null then
+        perform subscription_monthly_costs_add(p_subscription.user_id, p_subscription.service_name, v_end,
+                                               -p_sign * p_subscription.price);
+    end if;
+
+    for pause in select start_month, end_month from subscription_pauses where subscription_id = p_subscription.id
+        loop
+            perform subscription_monthly_costs_pause(p_subscription, pause.start_month, pause.end_month, p_sign);
+        end loop;
+end;
+$$;
+-- Modify "subscription_monthly_costs_track" function
+CREATE OR REPLACE FUNCTION "subscription_monthly_costs_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') and old.deleted_at is null then
+        perform subscription_monthly_costs_apply(old, -1);
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') and new.deleted_at is null then
+        perform subscription_monthly_costs_apply(new, 1);
+    end if;
+
+    return null;
+end;
+$$;
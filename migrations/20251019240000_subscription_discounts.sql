@@ -0,0 +1,178 @@
+-- Create "subscription_discounts" table
+CREATE TABLE "subscription_discounts" (
+  "id" bigserial NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "kind" character varying(20) NOT NULL,
+  "value" bigint NOT NULL,
+  "start_month" date NOT NULL,
+  "periods" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "removed_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "subscription_discounts_subscription_id_fkey" FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "subscription_discounts_kind_check" CHECK ((kind)::text = ANY ((ARRAY['percent'::character varying, 'fixed'::character varying])::text[])),
+  CONSTRAINT "subscription_discounts_value_check" CHECK (value > 0),
+  CONSTRAINT "subscription_discounts_periods_check" CHECK (periods > 0)
+);
+-- Create index "idx_subscription_discounts_subscription" to table: "subscription_discounts"
+CREATE INDEX "idx_subscription_discounts_subscription" ON "subscription_discounts" ("subscription_id") WHERE (removed_at IS NULL);
+-- Create "subscription_cost_changes" table
+CREATE TABLE "subscription_cost_changes" (
+  "subscription_id" bigint NOT NULL,
+  "user_id" character varying(50) NOT NULL,
+  "service_name" character varying(50) NOT NULL,
+  "month" date NOT NULL,
+  "delta" bigint NOT NULL,
+  PRIMARY KEY ("subscription_id", "month")
+);
+-- Create "subscription_discount" function
+CREATE FUNCTION "subscription_discount" ("p_subscription_id" bigint, "p_price" bigint, "p_month" date) RETURNS bigint LANGUAGE sql STABLE AS $$
+select coalesce(least(p_price, sum(case
+                                       when d.kind = 'percent' then round(p_price * d.value / 100.0)::bigint
+                                       else d.value end)), 0)::bigint
+from subscription_discounts d
+where d.subscription_id = p_subscription_id
+  and d.removed_at is null
+  and d.start_month <= p_month
+  and d.start_month + d.periods * interval '1 month' > p_month;
+$$;
+-- Create "subscription_costs" function
+CREATE FUNCTION "subscription_costs" ("p_subscription_id" bigint) RETURNS TABLE ("user_id" character varying, "service_name" character varying, "month" date, "delta" bigint) LANGUAGE sql STABLE AS $$
+with billed as (select s.id,
+                       s.user_id,
+                       s.service_name,
+                       s.price,
+                       date_trunc('month', greatest(s.start_date, s.trial_end_date))::date as start_month,
+                       date_trunc('month', s.end_date)::date                               as end_month
+                from subscriptions s
+                where s.id = p_subscription_id
+                  and s.deleted_at is null),
+     billing as (select b.*
+                 from billed b
+                 where b.end_month is null
+                    or b.end_month > b.start_month),
+     paused as (select greatest(p.start_month, b.start_month) as start_month,
+                       least(p.end_month, b.end_month)        as end_month
+                from subscription_pauses p
+                         join billing b on b.id = p.subscription_id
+                where least(p.end_month, b.end_month) is null
+                   or least(p.end_month, b.end_month) > greatest(p.start_month, b.start_month)),
+     discounted as (select m.month, subscription_discount(b.id, b.price, m.month) as reduction
+                    from billing b
+                             cross join lateral (select distinct g.month::date as month
+                                                 from subscription_discounts d
+                                                          cross join lateral generate_series(
+                                                         d.start_month::timestamp,
+                                                         d.start_month::timestamp + (d.periods - 1) * interval '1 month',
+                                                         interval '1 month') as g(month)
+                                                 where d.subscription_id = b.id
+                                                   and d.removed_at is null) m
+                    where m.month >= b.start_month
+                      and (b.end_month is null or m.month < b.end_month)
+                      and not exists (select 1
+                                      from paused pa
+                                      where pa.start_month <= m.month
+                                        and (pa.end_month is null or pa.end_month > m.month))),
+     changes as (select b.start_month as month, b.price as delta
+                 from billing b
+                 union all
+                 select b.end_month, -b.price
+                 from billing b
+                 where b.end_month is not null
+                 union all
+                 select pa.start_month, -b.price
+                 from paused pa,
+                      billing b
+                 union all
+                 select pa.end_month, b.price
+                 from paused pa,
+                      billing b
+                 where pa.end_month is not null
+                 union all
+                 select dc.month, -dc.reduction
+                 from discounted dc
+                 union all
+                 select (dc.month + interval '1 month')::date, dc.reduction
+                 from discounted dc)
+select b.user_id, b.service_name, c.month, sum(c.delta)::bigint
+from changes c
+         cross join billing b
+group by b.user_id, b.service_name, c.month
+having sum(c.delta) <> 0;
+$$;
+-- Create "subscription_monthly_costs_refresh" function
+CREATE FUNCTION "subscription_monthly_costs_refresh" ("p_subscription_id" bigint) RETURNS void LANGUAGE plpgsql AS $$
+declare
+    change record;
+begin
+    for change in delete from subscription_cost_changes
+                  where subscription_id = p_subscription_id
+                  returning user_id, service_name, month, delta
+        loop
+            perform subscription_monthly_costs_add(change.user_id, change.service_name, change.month, -change.delta);
+        end loop;
+
+    for change in insert into subscription_cost_changes (subscription_id, user_id, service_name, month, delta)
+                  select p_subscription_id, c.user_id, c.service_name, c.month, c.delta
+                  from subscription_costs(p_subscription_id) c
+                  returning user_id, service_name, month, delta
+        loop
+            perform subscription_monthly_costs_add(change.user_id, change.service_name, change.month, change.delta);
+        end loop;
+end;
+$$;
+-- Modify "subscription_monthly_costs_track" function
+CREATE OR REPLACE FUNCTION "subscription_monthly_costs_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    perform subscription_monthly_costs_refresh(coalesce(new.id, old.id));
+
+    return null;
+end;
+$$;
+-- Modify "subscription_pauses_track" function
+CREATE OR REPLACE FUNCTION "subscription_pauses_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform subscription_monthly_costs_refresh(old.subscription_id);
+    end if;
+
+    if tg_op = 'INSERT' or (tg_op = 'UPDATE' and new.subscription_id <> old.subscription_id) then
+        perform subscription_monthly_costs_refresh(new.subscription_id);
+    end if;
+
+    return null;
+end;
+$$;
+-- Create "subscription_discounts_track" function
+CREATE FUNCTION "subscription_discounts_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform subscription_monthly_costs_refresh(old.subscription_id);
+    end if;
+
+    if tg_op = 'INSERT' or (tg_op = 'UPDATE' and new.subscription_id <> old.subscription_id) then
+        perform subscription_monthly_costs_refresh(new.subscription_id);
+    end if;
+
+    return null;
+end;
+$$;
+-- Create trigger "subscription_discounts_track"
+CREATE TRIGGER "subscription_discounts_track" AFTER INSERT OR UPDATE OR DELETE ON "subscription_discounts" FOR EACH ROW EXECUTE FUNCTION "subscription_discounts_track"();
+-- Drop "subscription_monthly_costs_apply" function
+DROP FUNCTION "subscription_monthly_costs_apply" ("p_subscription" "subscriptions", "p_sign" integer);
+-- Drop "subscription_monthly_costs_pause" function
+DROP FUNCTION "subscription_monthly_costs_pause" ("p_subscription" "subscriptions", "p_start_month" date, "p_end_month" date, "p_sign" integer);
+-- Backfill the cost changes of the current subscriptions
+INSERT INTO "subscription_cost_changes" ("subscription_id", "user_id", "service_name", "month", "delta")
+SELECT "s"."id", "c"."user_id", "c"."service_name", "c"."month", "c"."delta"
+FROM "subscriptions" AS "s"
+         CROSS JOIN LATERAL subscription_costs("s"."id") AS "c"
+WHERE "s"."deleted_at" IS NULL;
+-- Rebuild the monthly costs from them
+DELETE FROM "subscription_monthly_costs";
+INSERT INTO "subscription_monthly_costs" ("user_id", "service_name", "month", "delta")
+SELECT "user_id", "service_name", "month", sum("delta")
+FROM "subscription_cost_changes"
+GROUP BY "user_id", "service_name", "month"
+HAVING sum("delta") <> 0;
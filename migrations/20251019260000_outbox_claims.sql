@@ -0,0 +1,2 @@
+-- Modify "outbox" table
+ALTER TABLE "outbox" ADD COLUMN "claimed_until" timestamptz NULL;
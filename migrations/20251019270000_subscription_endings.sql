@@ -0,0 +1,13 @@
+-- Create "subscription_endings" table
+CREATE TABLE "subscription_endings" (
+  "subscription_id" bigint NOT NULL,
+  "end_date" date NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("subscription_id", "end_date"),
+  CONSTRAINT "subscription_endings_subscription_id_fkey" FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Backfill "subscription_endings", endings before this migration are not announced
+INSERT INTO "subscription_endings" ("subscription_id", "end_date")
+SELECT "id", "end_date"
+FROM "subscriptions"
+WHERE "end_date" <= current_date;
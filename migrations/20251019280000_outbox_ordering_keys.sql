@@ -0,0 +1,6 @@
+-- Modify "outbox" table
+ALTER TABLE "outbox" ALTER COLUMN "subscription_id" DROP NOT NULL, ADD COLUMN "ordering_key" character varying(64) NULL;
+-- Key the events of budgets by the budget, they belong to no subscription
+UPDATE "outbox" SET "subscription_id" = NULL, "ordering_key" = 'budget:' || ("payload" -> 'budget' ->> 'id') WHERE "subscription_id" = 0;
+UPDATE "outbox" SET "ordering_key" = 'subscription:' || "subscription_id" WHERE "ordering_key" IS NULL;
+ALTER TABLE "outbox" ALTER COLUMN "ordering_key" SET NOT NULL;
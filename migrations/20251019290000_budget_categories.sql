@@ -0,0 +1,2 @@
+-- Modify "budgets" table
+ALTER TABLE "budgets" ADD COLUMN "category" character varying(50) NOT NULL DEFAULT '', ADD COLUMN "services" character varying(50)[] NOT NULL DEFAULT '{}', ADD CONSTRAINT "budgets_service_or_category" CHECK (((service_name)::text = ''::text) OR ((category)::text = ''::text));
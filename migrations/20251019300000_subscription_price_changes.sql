@@ -0,0 +1,136 @@
+-- Create "subscription_price_changes" table
+CREATE TABLE "subscription_price_changes" (
+  "id" bigserial NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "price" bigint NOT NULL,
+  "start_month" date NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "removed_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "subscription_price_changes_subscription_id_fkey" FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "subscription_price_changes_price_check" CHECK (price >= 0)
+);
+-- Create index "idx_subscription_price_changes_subscription" to table: "subscription_price_changes"
+CREATE INDEX "idx_subscription_price_changes_subscription" ON "subscription_price_changes" ("subscription_id") WHERE (removed_at IS NULL);
+-- Modify "subscription_costs" function
+CREATE OR REPLACE FUNCTION "subscription_costs" ("p_subscription_id" bigint, "p_as_of" timestamptz DEFAULT NULL, "p_include_deleted" boolean DEFAULT false) RETURNS TABLE ("user_id" character varying, "service_name" character varying, "month" date, "delta" bigint) LANGUAGE sql STABLE AS $$
+with subscription as (select s.id, s.user_id, s.service_name, s.price, s.start_date, s.end_date, s.trial_end_date,
+                             s.deleted_at
+                      from subscriptions s
+                      where p_as_of is null
+                        and s.id = p_subscription_id
+                      union all
+                      select v.id, v.user_id, v.service_name, v.price, v.start_date, v.end_date, v.trial_end_date,
+                             v.deleted_at
+                      from subscription_versions v
+                      where p_as_of is not null
+                        and v.id = p_subscription_id
+                        and v.valid_from <= p_as_of
+                        and (v.valid_to is null or v.valid_to > p_as_of)),
+     billing as (select s.id,
+                        s.user_id,
+                        s.service_name,
+                        s.price,
+                        date_trunc('month', greatest(s.start_date, s.trial_end_date))::date as start_month,
+                        date_trunc('month', s.end_date)::date                               as end_month
+                 from subscription s
+                 where (p_include_deleted or s.deleted_at is null)
+                   and (s.end_date is null or
+                        date_trunc('month', s.end_date) > date_trunc('month', greatest(s.start_date, s.trial_end_date)))),
+     paused as (select greatest(p.start_month, b.start_month) as start_month,
+                       least(p.end_month, b.end_month)        as end_month
+                from (select sp.start_month,
+                             case when p_as_of is null or sp.resumed_at <= p_as_of then sp.end_month end as end_month
+                      from subscription_pauses sp
+                      where sp.subscription_id = p_subscription_id
+                        and (p_as_of is null or sp.created_at <= p_as_of)) p
+                         cross join billing b
+                where least(p.end_month, b.end_month) is null
+                   or least(p.end_month, b.end_month) > greatest(p.start_month, b.start_month)),
+     scheduled as (select pc.id, pc.start_month as month, pc.price
+                   from subscription_price_changes pc
+                   where pc.subscription_id = p_subscription_id
+                     and ((p_as_of is null and pc.removed_at is null) or
+                          (pc.created_at <= p_as_of and (pc.removed_at is null or pc.removed_at > p_as_of)))),
+     boundaries as (select b.start_month as month
+                    from billing b
+                    union
+                    select b.end_month
+                    from billing b
+                    where b.end_month is not null
+                    union
+                    select pa.start_month
+                    from paused pa
+                    union
+                    select pa.end_month
+                    from paused pa
+                    where pa.end_month is not null
+                    union
+                    select sc.month
+                    from scheduled sc
+                    union
+                    select (d.start_month + g.period * interval '1 month')::date
+                    from subscription_discounts d
+                             cross join lateral generate_series(0, d.periods) as g(period)
+                    where d.subscription_id = p_subscription_id
+                      and (p_as_of is null or d.created_at <= p_as_of)
+                    union
+                    select m.start_month
+                    from subscription_members m
+                    where m.subscription_id = p_subscription_id
+                    union
+                    select m.end_month
+                    from subscription_members m
+                    where m.subscription_id = p_subscription_id
+                      and m.end_month is not null),
+     prices as (select bo.month,
+                       case
+                           when bo.month >= b.start_month
+                               and (b.end_month is null or bo.month < b.end_month)
+                               and not exists (select 1
+                                               from paused pa
+                                               where pa.start_month <= bo.month
+                                                 and (pa.end_month is null or pa.end_month > bo.month))
+                               then p.price - subscription_discount(b.id, p.price, bo.month, p_as_of)
+                           else 0 end as price
+                from boundaries bo
+                         cross join billing b
+                         cross join lateral (select coalesce((select sc.price
+                                                              from scheduled sc
+                                                              where sc.month <= bo.month
+                                                              order by sc.month desc, sc.id desc
+                                                              limit 1), b.price) as price) p),
+     amounts as (select pr.month, sh.user_id, sh.amount
+                 from prices pr
+                          cross join billing b
+                          cross join lateral subscription_shares(b.id, b.user_id, pr.price, pr.month, p_as_of) sh),
+     grid as (select bo.month, u.user_id, coalesce(sum(a.amount), 0) as amount
+              from boundaries bo
+                       cross join (select distinct a.user_id from amounts a) u
+                       left join amounts a on a.month = bo.month and a.user_id = u.user_id
+              group by bo.month, u.user_id),
+     deltas as (select g.user_id,
+                       g.month,
+                       g.amount - coalesce(lag(g.amount) over (partition by g.user_id order by g.month), 0) as delta
+                from grid g)
+select d.user_id, b.service_name, d.month, d.delta::bigint
+from deltas d
+         cross join billing b
+where d.delta <> 0;
+$$;
+-- Create "subscription_price_changes_track" function
+CREATE FUNCTION "subscription_price_changes_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform subscription_monthly_costs_refresh(old.subscription_id);
+    end if;
+
+    if tg_op = 'INSERT' or (tg_op = 'UPDATE' and new.subscription_id <> old.subscription_id) then
+        perform subscription_monthly_costs_refresh(new.subscription_id);
+    end if;
+
+    return null;
+end;
+$$;
+-- Create trigger "subscription_price_changes_track"
+CREATE TRIGGER "subscription_price_changes_track" AFTER INSERT OR UPDATE OR DELETE ON "subscription_price_changes" FOR EACH ROW EXECUTE FUNCTION "subscription_price_changes_track"();
@@ -0,0 +1,23 @@
+-- Modify "subscription_versions_track" function
+CREATE OR REPLACE FUNCTION "subscription_versions_track" () RETURNS trigger LANGUAGE plpgsql AS $$
+declare
+    -- the version closed and the version opened share the boundary
+    changed_at timestamptz := clock_timestamp();
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        update subscription_versions
+        set valid_to = changed_at
+        where id = old.id
+          and valid_to is null;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') then
+        insert into subscription_versions (id, service_name, price, user_id, start_date, end_date, deleted_at, status,
+                                           trial_end_date, valid_from)
+        values (new.id, new.service_name, new.price, new.user_id, new.start_date, new.end_date, new.deleted_at,
+                new.status, new.trial_end_date, changed_at);
+    end if;
+
+    return null;
+end;
+$$;
@@ -0,0 +1,111 @@
+-- Modify "subscription_costs" function
+CREATE OR REPLACE FUNCTION "subscription_costs" ("p_subscription_id" bigint, "p_as_of" timestamptz DEFAULT NULL, "p_include_deleted" boolean DEFAULT false) RETURNS TABLE ("user_id" character varying, "service_name" character varying, "month" date, "delta" bigint) LANGUAGE sql STABLE AS $$
+with subscription as (select s.id, s.user_id, s.service_name, s.price, s.start_date, s.end_date, s.trial_end_date,
+                             s.deleted_at
+                      from subscriptions s
+                      where p_as_of is null
+                        and s.id = p_subscription_id
+                      union all
+                      select v.id, v.user_id, v.service_name, v.price, v.start_date, v.end_date, v.trial_end_date,
+                             v.deleted_at
+                      from subscription_versions v
+                      where p_as_of is not null
+                        and v.id = p_subscription_id
+                        and v.valid_from <= p_as_of
+                        and (v.valid_to is null or v.valid_to > p_as_of)),
+     billing as (select s.id,
+                        s.user_id,
+                        s.service_name,
+                        s.price,
+                        date_trunc('month', greatest(s.start_date, s.trial_end_date))::date as start_month,
+                        date_trunc('month', s.end_date)::date                               as end_month
+                 from subscription s
+                 where (p_include_deleted or s.deleted_at is null)
+                   and (s.end_date is null or
+                        date_trunc('month', s.end_date) > date_trunc('month', greatest(s.start_date, s.trial_end_date)))),
+     paused as (select greatest(p.start_month, b.start_month) as start_month,
+                       least(p.end_month, b.end_month)        as end_month
+                from (select sp.start_month,
+                             case when p_as_of is null or sp.resumed_at <= p_as_of then sp.end_month end as end_month
+                      from subscription_pauses sp
+                      where sp.subscription_id = p_subscription_id
+                        and (p_as_of is null or sp.created_at <= p_as_of)) p
+                         cross join billing b
+                where least(p.end_month, b.end_month) is null
+                   or least(p.end_month, b.end_month) > greatest(p.start_month, b.start_month)),
+     discounted as (select m.month, subscription_discount(b.id, b.price, m.month, p_as_of) as reduction
+                    from billing b
+                             cross join lateral (select distinct g.month::date as month
+                                                 from subscription_discounts d
+                                                          cross join lateral generate_series(
+                                                         d.start_month::timestamp,
+                                                         d.start_month::timestamp + (d.periods - 1) * interval '1 month',
+                                                         interval '1 month') as g(month)
+                                                 where d.subscription_id = b.id
+                                                   and (p_as_of is null or d.created_at <= p_as_of)) m
+                    where m.month >= b.start_month
+                      and (b.end_month is null or m.month < b.end_month)
+                      and not exists (select 1
+                                      from paused pa
+                                      where pa.start_month <= m.month
+                                        and (pa.end_month is null or pa.end_month > m.month))),
+     changes as (select b.start_month as month, b.price as delta
+                 from billing b
+                 union all
+                 select b.end_month, -b.price
+                 from billing b
+                 where b.end_month is not null
+                 union all
+                 select pa.start_month, -b.price
+                 from paused pa,
+                      billing b
+                 union all
+                 select pa.end_month, b.price
+                 from paused pa,
+                      billing b
+                 where pa.end_month is not null
+                 union all
+                 select dc.month, -dc.reduction
+                 from discounted dc
+                 union all
+                 select (dc.month + interval '1 month')::date, dc.reduction
+                 from discounted dc),
+     boundaries as (select c.month
+                    from changes c
+                    union
+                    select m.start_month
+                    from subscription_members m
+                    where m.subscription_id = p_subscription_id
+                    union
+                    select m.end_month
+                    from subscription_members m
+                    where m.subscription_id = p_subscription_id
+                      and m.end_month is not null),
+     amounts as (select bo.month, sh.user_id, sh.amount
+                 from boundaries bo
+                          cross join billing b
+                          cross join lateral (select coalesce(sum(c.delta), 0)::bigint as price
+                                              from changes c
+                                              where c.month <= bo.month) p
+                          cross join lateral subscription_shares(b.id, b.user_id, p.price, bo.month, p_as_of) sh),
+     grid as (select bo.month, u.user_id, coalesce(sum(a.amount), 0) as amount
+              from boundaries bo
+                       cross join (select distinct a.user_id from amounts a) u
+                       left join amounts a on a.month = bo.month and a.user_id = u.user_id
+              group by bo.month, u.user_id),
+     deltas as (select g.user_id,
+                       g.month,
+                       g.amount - coalesce(lag(g.amount) over (partition by g.user_id order by g.month), 0) as delta
+                from grid g)
+select d.user_id, b.service_name, d.month, d.delta::bigint
+from deltas d
+         cross join billing b
+where d.delta <> 0;
+$$;
+-- Drop trigger "subscription_price_changes_track" from table: "subscription_price_changes"
+DROP TRIGGER "subscription_price_changes_track" ON "subscription_price_changes";
+-- Drop "subscription_price_changes_track" function
+DROP FUNCTION "subscription_price_changes_track" ();
+-- Refresh the monthly costs of the subscriptions with scheduled prices
+SELECT subscription_monthly_costs_refresh("subscription_id")
+FROM (SELECT DISTINCT "subscription_id" FROM "subscription_price_changes") AS "p";
@@ -1,11 +1,600 @@
+create extension if not exists btree_gist;
+
 create table subscriptions
 (
-    id           bigserial primary key,
-    service_name varchar(50) not null,
-    price        bigint      not null,
+    id             bigserial primary key,
+    service_name   varchar(50) not null,
+    price          bigint      not null,
+    user_id        varchar(50) not null,
+    start_date     date        not null,
+    end_date       date,
+    deleted_at     timestamptz,
+    no_overlap     boolean     not null default false,
+    status         varchar(20) not null default 'active'
+        constraint subscriptions_status_check check (status in ('active', 'paused', 'cancelled', 'trial')),
+    trial_end_date date,
+    constraint subscriptions_no_overlap exclude using gist (
+        user_id with =,
+        service_name with =,
+        daterange(start_date, end_date) with &&
+        ) where (deleted_at is null and no_overlap)
+);
+
+create index idx_subscriptions_user_service_date on subscriptions (user_id, service_name, start_date, end_date);
+
+create index idx_subscriptions_active on subscriptions (start_date, end_date) where deleted_at is null;
+
+create index idx_subscriptions_deleted_at on subscriptions (deleted_at) where deleted_at is not null;
+
+create index idx_subscriptions_trial on subscriptions (trial_end_date) where status = 'trial';
+
+create table subscription_audit
+(
+    id              bigserial primary key,
+    subscription_id bigint      not null,
+    actor           varchar(50) not null,
+    action          varchar(20) not null,
+    before          jsonb,
+    after           jsonb,
+    request_id      varchar(64) not null default '',
+    created_at      timestamptz not null default now()
+);
+
+create index idx_subscription_audit_subscription_created on subscription_audit (subscription_id, created_at);
+
+create function subscription_audit_append_only() returns trigger
+    language plpgsql as
+$$
+begin
+    raise exception 'subscription_audit is append-only';
+end;
+$$;
+
+create trigger subscription_audit_append_only
+    before update or delete
+    on subscription_audit
+    for each row
+execute function subscription_audit_append_only();
+
+
+create table subscription_versions
+(
+    version_id     bigserial primary key,
+    id             bigint      not null,
+    service_name   varchar(50) not null,
+    price          bigint      not null,
+    user_id        varchar(50) not null,
+    start_date     date        not null,
+    end_date       date,
+    deleted_at     timestamptz,
+    status         varchar(20) not null default 'active',
+    trial_end_date date,
+    valid_from     timestamptz not null,
+    valid_to       timestamptz
+);
+
+create index idx_subscription_versions_id_valid on subscription_versions (id, valid_from, valid_to);
+
+create index idx_subscription_versions_user_service_valid on subscription_versions (user_id, service_name, valid_from, valid_to);
+
+create function subscription_versions_track() returns trigger
+    language plpgsql as
+$$
+declare
+    -- the version closed and the version opened share the boundary
+    changed_at timestamptz := clock_timestamp();
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        update subscription_versions
+        set valid_to = changed_at
+        where id = old.id
+          and valid_to is null;
+    end if;
+
+    if tg_op in ('INSERT', 'UPDATE') then
+        insert into subscription_versions (id, service_name, price, user_id, start_date, end_date, deleted_at, status,
+                                           trial_end_date, valid_from)
+        values (new.id, new.service_name, new.price, new.user_id, new.start_date, new.end_date, new.deleted_at,
+                new.status, new.trial_end_date, changed_at);
+    end if;
+
+    return null;
+end;
+$$;
+
+create trigger subscription_versions_track
+    after insert or update or delete
+    on subscriptions
+    for each row
+execute function subscription_versions_track();
+
+
+create table subscription_monthly_costs
+(
     user_id      varchar(50) not null,
-    start_date   date        not null,
-    end_date     date
+    service_name varchar(50) not null,
+    month        date        not null,
+    delta        bigint      not null,
+    primary key (user_id, service_name, month)
+);
+
+create function subscription_monthly_costs_add(p_user_id varchar, p_service_name varchar, p_month date,
+                                               p_delta bigint) returns void
+    language plpgsql as
+$$
+begin
+    insert into subscription_monthly_costs as c (user_id, service_name, month, delta)
+    values (p_user_id, p_service_name, p_month, p_delta)
+    on conflict (user_id, service_name, month) do update
+        set delta = c.delta + excluded.delta;
+
+    delete
+    from subscription_monthly_costs
+    where user_id = p_user_id
+      and service_name = p_service_name
+      and month = p_month
+      and delta = 0;
+end;
+$$;
+
+create table subscription_cost_changes
+(
+    subscription_id bigint      not null,
+    user_id         varchar(50) not null,
+    service_name    varchar(50) not null,
+    month           date        not null,
+    delta           bigint      not null,
+    primary key (subscription_id, user_id, month)
+);
+
+create function subscription_monthly_costs_track() returns trigger
+    language plpgsql as
+$$
+begin
+    perform subscription_monthly_costs_refresh(coalesce(new.id, old.id));
+
+    return null;
+end;
+$$;
+
+create trigger subscription_monthly_costs_track
+    after insert or update or delete
+    on subscriptions
+    for each row
+execute function subscription_monthly_costs_track();
+
+
+create table subscription_endings
+(
+    subscription_id bigint      not null references subscriptions (id) on delete cascade,
+    end_date        date        not null,
+    created_at      timestamptz not null default now(),
+    primary key (subscription_id, end_date)
+);
+
+create table subscription_pauses
+(
+    id              bigserial primary key,
+    subscription_id bigint      not null references subscriptions (id) on delete cascade,
+    start_month     date        not null,
+    end_month       date,
+    created_at      timestamptz not null default now(),
+    resumed_at      timestamptz
+);
+
+create unique index idx_subscription_pauses_open on subscription_pauses (subscription_id) where resumed_at is null;
+
+create index idx_subscription_pauses_subscription on subscription_pauses (subscription_id, start_month);
+
+create function subscription_pauses_track() returns trigger
+    language plpgsql as
+$$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform subscription_monthly_costs_refresh(old.subscription_id);
+    end if;
+
+    if tg_op = 'INSERT' or (tg_op = 'UPDATE' and new.subscription_id <> old.subscription_id) then
+        perform subscription_monthly_costs_refresh(new.subscription_id);
+    end if;
+
+    return null;
+end;
+$$;
+
+create trigger subscription_pauses_track
+    after insert or update or delete
+    on subscription_pauses
+    for each row
+execute function subscription_pauses_track();
+
+
+create table subscription_discounts
+(
+    id              bigserial primary key,
+    subscription_id bigint      not null references subscriptions (id) on delete cascade,
+    kind            varchar(20) not null check (kind in ('percent', 'fixed')),
+    value           bigint      not null check (value > 0),
+    start_month     date        not null,
+    periods         integer     not null check (periods > 0),
+    created_at      timestamptz not null default now(),
+    removed_at      timestamptz
+);
+
+create index idx_subscription_discounts_subscription on subscription_discounts (subscription_id) where removed_at is null;
+
+create function subscription_discounts_track() returns trigger
+    language plpgsql as
+$$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform subscription_monthly_costs_refresh(old.subscription_id);
+    end if;
+
+    if tg_op = 'INSERT' or (tg_op = 'UPDATE' and new.subscription_id <> old.subscription_id) then
+        perform subscription_monthly_costs_refresh(new.subscription_id);
+    end if;
+
+    return null;
+end;
+$$;
+
+create trigger subscription_discounts_track
+    after insert or update or delete
+    on subscription_discounts
+    for each row
+execute function subscription_discounts_track();
+
+create table subscription_members
+(
+    id              bigserial primary key,
+    subscription_id bigint      not null references subscriptions (id) on delete cascade,
+    user_id         varchar(50) not null,
+    share           integer check (share between 1 and 100),
+    start_month     date        not null,
+    end_month       date,
+    created_at      timestamptz not null default now(),
+    removed_at      timestamptz,
+    constraint subscription_members_no_overlap exclude using gist (subscription_id with =, user_id with =,
+                                                                   daterange(start_month, end_month) with &&)
+);
+
+create index idx_subscription_members_user on subscription_members (user_id);
+
+create function subscription_members_track() returns trigger
+    language plpgsql as
+$$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform subscription_monthly_costs_refresh(old.subscription_id);
+    end if;
+
+    if tg_op = 'INSERT' or (tg_op = 'UPDATE' and new.subscription_id <> old.subscription_id) then
+        perform subscription_monthly_costs_refresh(new.subscription_id);
+    end if;
+
+    return null;
+end;
+$$;
+
+create trigger subscription_members_track
+    after insert or update or delete
+    on subscription_members
+    for each row
+execute function subscription_members_track();
+
+create table subscription_price_changes
+(
+    id              bigserial primary key,
+    subscription_id bigint      not null references subscriptions (id) on delete cascade,
+    price           bigint      not null check (price >= 0),
+    start_month     date        not null,
+    created_at      timestamptz not null default now(),
+    removed_at      timestamptz
+);
+
+create index idx_subscription_price_changes_subscription on subscription_price_changes (subscription_id) where removed_at is null;
+
+create function subscription_shares(p_subscription_id bigint, p_owner varchar, p_price bigint, p_month date,
+                                    p_as_of timestamptz default null)
+    returns table
+            (
+                user_id varchar,
+                amount  bigint
+            )
+    language sql
+    stable as
+$$
+with members as (select m.user_id, m.share
+                 from subscription_members m
+                 where m.subscription_id = p_subscription_id
+                   and (p_as_of is null or m.created_at <= p_as_of)
+                   and m.start_month <= p_month
+                   and (m.end_month is null or m.end_month > p_month or m.removed_at > p_as_of)),
+     explicit as (select m.user_id, p_price * m.share / 100 as amount
+                  from members m
+                  where m.share is not null),
+     remainder as (select p_price - coalesce((select sum(e.amount) from explicit e), 0)::bigint as amount,
+                          (select count(*) from members m where m.share is null) + 1 as parts)
+select e.user_id, e.amount
+from explicit e
+union all
+select m.user_id, r.amount / r.parts
+from members m,
+     remainder r
+where m.share is null
+union all
+select p_owner, r.amount - (r.parts - 1) * (r.amount / r.parts)
+from remainder r;
+$$;
+
+create function subscription_discount(p_subscription_id bigint, p_price bigint, p_month date,
+                                      p_as_of timestamptz default null) returns bigint
+    language sql
+    stable as
+$$
+select coalesce(least(p_price, sum(case
+                                       when d.kind = 'percent' then round(p_price * d.value / 100.0)::bigint
+                                       else d.value end)), 0)::bigint
+from subscription_discounts d
+where d.subscription_id = p_subscription_id
+  and ((p_as_of is null and d.removed_at is null) or
+       (d.created_at <= p_as_of and (d.removed_at is null or d.removed_at > p_as_of)))
+  and d.start_month <= p_month
+  and d.start_month + d.periods * interval '1 month' > p_month;
+$$;
+
+create function subscription_costs(p_subscription_id bigint, p_as_of timestamptz default null,
+                                   p_include_deleted boolean default false)
+    returns table
+            (
+                user_id      varchar,
+                service_name varchar,
+                month        date,
+                delta        bigint
+            )
+    language sql
+    stable as
+$$
+with subscription as (select s.id, s.user_id, s.service_name, s.price, s.start_date, s.end_date, s.trial_end_date,
+                             s.deleted_at
+                      from subscriptions s
+                      where p_as_of is null
+                        and s.id = p_subscription_id
+                      union all
+                      select v.id, v.user_id, v.service_name, v.price, v.start_date, v.end_date, v.trial_end_date,
+                             v.deleted_at
+                      from subscription_versions v
+                      where p_as_of is not null
+                        and v.id = p_subscription_id
+                        and v.valid_from <= p_as_of
+                        and (v.valid_to is null or v.valid_to > p_as_of)),
+     billing as (select s.id,
+                        s.user_id,
+                        s.service_name,
+                        s.price,
+                        date_trunc('month', greatest(s.start_date, s.trial_end_date))::date as start_month,
+                        date_trunc('month', s.end_date)::date                               as end_month
+                 from subscription s
+                 where (p_include_deleted or s.deleted_at is null)
+                   and (s.end_date is null or
+                        date_trunc('month', s.end_date) > date_trunc('month', greatest(s.start_date, s.trial_end_date)))),
+     paused as (select greatest(p.start_month, b.start_month) as start_month,
+                       least(p.end_month, b.end_month)        as end_month
+                from (select sp.start_month,
+                             case when p_as_of is null or sp.resumed_at <= p_as_of then sp.end_month end as end_month
+                      from subscription_pauses sp
+                      where sp.subscription_id = p_subscription_id
+                        and (p_as_of is null or sp.created_at <= p_as_of)) p
+                         cross join billing b
+                where least(p.end_month, b.end_month) is null
+                   or least(p.end_month, b.end_month) > greatest(p.start_month, b.start_month)),
+     discounted as (select m.month, subscription_discount(b.id, b.price, m.month, p_as_of) as reduction
+                    from billing b
+                             cross join lateral (select distinct g.month::date as month
+                                                 from subscription_discounts d
+                                                          cross join lateral generate_series(
+                                                         d.start_month::timestamp,
+                                                         d.start_month::timestamp + (d.periods - 1) * interval '1 month',
+                                                         interval '1 month') as g(month)
+                                                 where d.subscription_id = b.id
+                                                   and (p_as_of is null or d.created_at <= p_as_of)) m
+                    where m.month >= b.start_month
+                      and (b.end_month is null or m.month < b.end_month)
+                      and not exists (select 1
+                                      from paused pa
+                                      where pa.start_month <= m.month
+                                        and (pa.end_month is null or pa.end_month > m.month))),
+     changes as (select b.start_month as month, b.price as delta
+                 from billing b
+                 union all
+                 select b.end_month, -b.price
+                 from billing b
+                 where b.end_month is not null
+                 union all
+                 select pa.start_month, -b.price
+                 from paused pa,
+                      billing b
+                 union all
+                 select pa.end_month, b.price
+                 from paused pa,
+                      billing b
+                 where pa.end_month is not null
+                 union all
+                 select dc.month, -dc.reduction
+                 from discounted dc
+                 union all
+                 select (dc.month + interval '1 month')::date, dc.reduction
+                 from discounted dc),
+     boundaries as (select c.month
+                    from changes c
+                    union
+                    select m.start_month
+                    from subscription_members m
+                    where m.subscription_id = p_subscription_id
+                    union
+                    select m.end_month
+                    from subscription_members m
+                    where m.subscription_id = p_subscription_id
+                      and m.end_month is not null),
+     amounts as (select bo.month, sh.user_id, sh.amount
+                 from boundaries bo
+                          cross join billing b
+                          cross join lateral (select coalesce(sum(c.delta), 0)::bigint as price
+                                              from changes c
+                                              where c.month <= bo.month) p
+                          cross join lateral subscription_shares(b.id, b.user_id, p.price, bo.month, p_as_of) sh),
+     grid as (select bo.month, u.user_id, coalesce(sum(a.amount), 0) as amount
+              from boundaries bo
+                       cross join (select distinct a.user_id from amounts a) u
+                       left join amounts a on a.month = bo.month and a.user_id = u.user_id
+              group by bo.month, u.user_id),
+     deltas as (select g.user_id,
+                       g.month,
+                       g.amount - coalesce(lag(g.amount) over (partition by g.user_id order by g.month), 0) as delta
+                from grid g)
+select d.user_id, b.service_name, d.month, d.delta::bigint
+from deltas d
+         cross join billing b
+where d.delta <> 0;
+$$;
+
+create function subscription_monthly_costs_refresh(p_subscription_id bigint) returns void
+    language plpgsql as
+$$
+declare
+    change record;
+begin
+    for change in delete from subscription_cost_changes
+                  where subscription_id = p_subscription_id
+                  returning user_id, service_name, month, delta
+        loop
+            perform subscription_monthly_costs_add(change.user_id, change.service_name, change.month, -change.delta);
+        end loop;
+
+    for change in insert into subscription_cost_changes (subscription_id, user_id, service_name, month, delta)
+                  select p_subscription_id, c.user_id, c.service_name, c.month, c.delta
+                  from subscription_costs(p_subscription_id) c
+                  returning user_id, service_name, month, delta
+        loop
+            perform subscription_monthly_costs_add(change.user_id, change.service_name, change.month, change.delta);
+        end loop;
+end;
+$$;
+
+
+create table webhooks
+(
+    id         bigserial primary key,
+    url        text        not null,
+    events     text[]      not null,
+    secret     text        not null,
+    created_at timestamptz not null default now()
+);
+
+create table webhook_deliveries
+(
+    id              bigserial primary key,
+    webhook_id      bigint      not null references webhooks (id) on delete cascade,
+    event           varchar(50) not null,
+    payload         jsonb       not null,
+    status          varchar(20) not null default 'pending',
+    attempts        integer     not null default 0,
+    next_attempt_at timestamptz not null default now(),
+    last_error      text,
+    created_at      timestamptz not null default now(),
+    delivered_at    timestamptz
 );
 
-create index idx_subscriptions_user_service_date on subscriptions (user_id, service_name, start_date, end_date);
\ No newline at end of file
+create index idx_webhook_deliveries_pending on webhook_deliveries (next_attempt_at) where status = 'pending';
+
+create index idx_webhook_deliveries_webhook on webhook_deliveries (webhook_id, id);
+
+
+create table outbox
+(
+    id              bigserial primary key,
+    subscription_id bigint,
+    ordering_key    varchar(64) not null,
+    user_id         varchar(50) not null default '',
+    service_name    varchar(50) not null default '',
+    type            varchar(50) not null,
+    payload         jsonb       not null,
+    occurred_at     timestamptz not null default now(),
+    published_at    timestamptz,
+    claimed_until   timestamptz
+);
+
+create index idx_outbox_unpublished on outbox (id) where published_at is null;
+
+create index idx_outbox_user_service on outbox (user_id, service_name, id);
+
+
+create table notification_preferences
+(
+    user_id           varchar(50) primary key,
+    channel           varchar(20) not null default 'log',
+    address           text        not null default '',
+    renewal_reminders boolean     not null default true,
+    end_reminders     boolean     not null default true,
+    monthly_summaries boolean     not null default true,
+    locale            varchar(10) not null default 'en'
+);
+
+create table reminders
+(
+    subscription_id bigint      not null,
+    kind            varchar(20) not null,
+    due_date        date        not null,
+    channel         varchar(20) not null,
+    sent_at         timestamptz not null default now(),
+    primary key (subscription_id, kind, due_date)
+);
+
+
+create table email_queue
+(
+    id              bigserial primary key,
+    user_id         varchar(50) not null,
+    kind            varchar(50) not null,
+    locale          varchar(10) not null default 'en',
+    address         text        not null,
+    data            jsonb       not null default '{}',
+    dedup_key       text unique,
+    status          varchar(20) not null default 'pending',
+    attempts        integer     not null default 0,
+    next_attempt_at timestamptz not null default now(),
+    last_error      text,
+    created_at      timestamptz not null default now(),
+    sent_at         timestamptz
+);
+
+create index idx_email_queue_pending on email_queue (next_attempt_at) where status = 'pending';
+
+
+create table budgets
+(
+    id           bigserial     primary key,
+    user_id      varchar(50)   not null,
+    service_name varchar(50)   not null default '',
+    category     varchar(50)   not null default '',
+    services     varchar(50)[] not null default '{}',
+    amount       bigint        not null check (amount > 0),
+    currency     char(3)       not null default 'RUB',
+    period       varchar(10)   not null default 'month' check (period in ('month', 'year')),
+    thresholds   integer[]     not null default '{80,100}',
+    created_at   timestamptz   not null default now(),
+    constraint budgets_service_or_category check (service_name = '' or category = '')
+);
+
+create index idx_budgets_user on budgets (user_id);
+
+create table budget_alerts
+(
+    budget_id    bigint      not null references budgets (id) on delete cascade,
+    period_start date        not null,
+    threshold    integer     not null,
+    spent        bigint      not null,
+    created_at   timestamptz not null default now(),
+    primary key (budget_id, period_start, threshold)
+);